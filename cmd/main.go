@@ -1,70 +1,595 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"city-hall-lights/internal/bot"
+	"city-hall-lights/internal/datadir"
+	"city-hall-lights/internal/ical"
 	"city-hall-lights/internal/model"
 	"city-hall-lights/internal/scraper"
 	"city-hall-lights/internal/store"
+	"github.com/joho/godotenv"
 )
 
 func main() {
-	fs := store.NewFileStore()
-	var event *model.Event
-	// check if events have already been parsed into a file
-	exists, err := fs.CheckFileExists()
+	if err := godotenv.Load(); err != nil {
+		fmt.Println("no .env file loaded, using environment")
+	}
+	dataDir := flag.String("data-dir", "", fmt.Sprintf(`directory of events, images, the ledger and caches (default: $%s, else %s)`, datadir.EnvVar, datadir.Default()))
+	flag.Parse()
+	dir := datadir.Resolve(*dataDir)
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "init" {
+		os.Exit(initDataDir(dir))
+	}
+	// data used to be kept in the repository, which an upgraded deployment would silently stop reading
+	if dir != datadir.Legacy && !dir.HasEvents() && datadir.Legacy.HasEvents() {
+		fmt.Println(fmt.Sprintf(`warning: %s has no events but %s does, set %s=%s to keep using it`, dir, datadir.Legacy, datadir.EnvVar, datadir.Legacy))
+	}
+
+	cfg := scraper.ConfigFromEnv(dir)
+	backend, err := store.Open(store.ConfigFromEnv(dir))
 	if err != nil {
-		fmt.Println("failed to check file: ", err)
+		fmt.Println("failed to open store: ", err)
 		os.Exit(1)
 	}
 
-	// if there is an event today, post it
-	if exists {
-		event, err = fs.Read(time.Now())
-		if err != nil {
-			fmt.Println("failed to read event: ", err)
+	if len(args) > 0 {
+		switch args[0] {
+		case "import":
+			if len(args) != 2 {
+				fmt.Println("usage: city-hall-lights import <file or directory of saved schedule pages>")
+				os.Exit(2)
+			}
+			os.Exit(importSchedules(backend, cfg, args[1]))
+		case "import-json":
+			if len(args) > 2 {
+				fmt.Println("usage: city-hall-lights import-json [directory of JSON month files]")
+				os.Exit(2)
+			}
+			from := store.NewFileStore().In(dir.Events())
+			if len(args) == 2 {
+				from = from.In(args[1])
+			}
+			os.Exit(importJSON(&from, backend))
+		case "events":
+			os.Exit(listEvents(backend, args[1:]))
+		case "migrate":
+			os.Exit(migrateFiles(backend))
+		case "history":
+			os.Exit(showHistory(backend, args[1:]))
+		case "override":
+			os.Exit(overrideEvent(backend, args[1:]))
+		case "ics":
+			os.Exit(exportCalendar(backend, cfg, args[1:]))
+		case "serve":
+			os.Exit(serveCalendar(backend, cfg, args[1:]))
+		default:
+			fmt.Println(fmt.Sprintf(`unknown command %q`, args[0]))
+			os.Exit(2)
 		}
-		if event == nil {
+	}
+
+	// check if events have already been parsed into the store
+	exists, err := backend.HasMonth()
+	if err != nil {
+		fmt.Println("failed to check store: ", err)
+		os.Exit(1)
+	}
+
+	// if there is an event today, post it, after picking up any changes made to the schedule since it was stored
+	if exists {
+		now := time.Now()
+		night := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		changes := reconcileMonth(backend, cfg)
+		correctPosts(backend, dir, changes, night, now)
+
+		event, err := backend.Read(now)
+		if errors.Is(err, store.ErrNotFound) {
 			fmt.Println("no event today")
+			printNext(backend, night)
 			os.Exit(0)
 		}
+		if err != nil {
+			fmt.Println("failed to read event: ", err)
+			os.Exit(1)
+		}
 		fmt.Println(fmt.Sprintf(`today's event: %s`, event.Description))
-		bot.CreateAndSendPost(event)
+		if _, err = bot.CreateAndSendPost(backend, dir, &event, night); err != nil {
+			fmt.Println("failed to post today's event: ", err)
+			os.Exit(1)
+		}
 		os.Exit(0)
 	}
 
-	// check if events for the current month have been posted to the website
-	newDataAvail, err := scraper.CheckPageLastUpdated()
+	// fetch the page once, then check if events for the current month have been posted to the website
+	page, err := fetchPage(cfg)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Println("failed to scrape lighting schedule: ", err)
 		os.Exit(1)
 	}
+	if page.Cached {
+		fmt.Println("page not modified since ", page.FetchedAt)
+	}
+	recordScrapeRun(backend, page)
 
-	if !newDataAvail {
-		fmt.Println("no new data available, exiting")
+	status := scraper.CheckPublication(page, time.Now())
+	fmt.Println(fmt.Sprintf(`lighting schedule heading: %q`, status.Heading))
+	if status.NextMonthPosted {
+		fmt.Println(fmt.Sprintf(`page already lists %s, this month's schedule is gone`, status.Published.Format("January 2006")))
+	}
+	if !status.IsTargetMonth {
+		fmt.Println(fmt.Sprintf(`no new data available, page lists %s, exiting`, status.Published.Format("January 2006")))
 		os.Exit(0)
 	}
 
-	// if not, scrape the website and store the events in a file
-	scrapedEvents, err := scraper.Scrape()
-	if err != nil {
-		fmt.Println(err)
-	}
-	fmt.Println(fmt.Sprintf(`Found %d events`, len(scrapedEvents)))
-	for _, event := range scrapedEvents {
+	// if so, store the scraped events
+	schedule := page.Schedule
+	fmt.Println(fmt.Sprintf(`Found %d events`, len(schedule.Events)))
+	for _, event := range schedule.Events {
 		fmt.Println(fmt.Sprintf(`%+v`, event))
+		for _, color := range event.Colors {
+			if color.Unknown {
+				fmt.Println(fmt.Sprintf(`unknown color %q in %q, add it to the palette`, color.Name, event.DateString))
+			}
+		}
+	}
+	for _, line := range schedule.Unparsed {
+		fmt.Println(fmt.Sprintf(`rejected line at %s stage: %q (offending %q, suggestion: %s)`,
+			line.Stage, line.RawEventString, line.Offending, line.Suggestion))
 	}
-	if err = fs.Create(scrapedEvents); err != nil {
-		fmt.Println("failed to persist events to file: ", err)
+	fmt.Println(fmt.Sprintf(`parsed %d lines, rejected %d`, len(schedule.Events), len(schedule.Unparsed)))
+	// rejected lines are quarantined first so that a git backend commits them with the events; a clean
+	// schedule clears the lines quarantined from an earlier one
+	if err = backend.Quarantine(schedule.Unparsed); err != nil {
+		fmt.Println("failed to quarantine rejected lines: ", err)
 		os.Exit(1)
 	}
-	fmt.Println("successfully persisted events to file")
+	if err = store.StoreSchedule(backend, pageSource(page), schedule.Events); err != nil {
+		fmt.Println("failed to persist events: ", err)
+		os.Exit(1)
+	}
+	fmt.Println("successfully persisted events")
 	os.Exit(0)
 }
 
+// reconcileMonth re-scrapes the schedule and applies any changes to the stored month. The page says the
+// schedule is subject to change, so this runs daily; failures are reported but don't stop today's post.
+func reconcileMonth(backend store.Backend, cfg scraper.Config) []model.Change {
+	page, err := fetchPage(cfg)
+	if err != nil {
+		fmt.Println("failed to re-scrape lighting schedule: ", err)
+		return nil
+	}
+	recordScrapeRun(backend, page)
+	if !scraper.CheckPublication(page, time.Now()).IsTargetMonth {
+		fmt.Println("page no longer lists this month, keeping stored events")
+		return nil
+	}
+	// rejected lines are quarantined first so that a git backend commits them with the changes; lines the city
+	// has since corrected are cleared
+	if err = backend.Quarantine(page.Schedule.Unparsed); err != nil {
+		fmt.Println("failed to quarantine rejected lines: ", err)
+	}
+	changes, err := store.Reconcile(backend, pageSource(page), page.Schedule.Events)
+	if err != nil {
+		fmt.Println("failed to reconcile stored events: ", err)
+		return nil
+	}
+	for _, change := range changes {
+		fmt.Println(fmt.Sprintf(`schedule change: %s %s`, change.Kind, change.Key))
+	}
+	return changes
+}
+
+// fetchPage fetches the lighting schedule page and reports how the schedule was found on it.
+func fetchPage(cfg scraper.Config) (scraper.Page, error) {
+	page, err := scraper.Fetch(cfg)
+	if page.CacheErr != nil {
+		fmt.Println("ignoring page cache: ", page.CacheErr)
+	}
+	if err != nil {
+		return page, err
+	}
+	fmt.Println("lighting schedule located by", page.Schedule.Locator)
+	return page, nil
+}
+
+func pageSource(page scraper.Page) model.Source {
+	return model.Source{ScrapedAt: page.FetchedAt, URL: page.URL, Hash: page.Hash}
+}
+
+func recordScrapeRun(backend store.Backend, page scraper.Page) {
+	err := backend.RecordScrapeRun(model.ScrapeRun{
+		FetchedAt: time.Now(),
+		URL:       page.URL,
+		Hash:      page.Hash,
+		Month:     page.Month,
+		Cached:    page.Cached,
+		Events:    len(page.Schedule.Events),
+		Rejected:  len(page.Schedule.Unparsed),
+	})
+	if err != nil {
+		fmt.Println("failed to record scrape run: ", err)
+	}
+}
+
+// correctPosts follows up on tonight's post when its event changed after it was published. With
+// BLUESKY_REPOST_CORRECTIONS=true the post is replaced instead when the lights aren't on yet.
+func correctPosts(backend store.Backend, dir datadir.Dir, changes []model.Change, night time.Time, now time.Time) {
+	if len(changes) == 0 {
+		return
+	}
+	posted, err := backend.PostedOn(night)
+	if err != nil {
+		fmt.Println("failed to read posts: ", err)
+		return
+	}
+	corrections := bot.PlanCorrections(posted, changes, now, os.Getenv("BLUESKY_REPOST_CORRECTIONS") == "true")
+	for _, correction := range corrections {
+		fmt.Println(fmt.Sprintf(`correcting %s: %s`, correction.Post.URI, correction.Text))
+	}
+	if err = bot.SendCorrections(backend, dir, corrections); err != nil {
+		fmt.Println("failed to send corrections: ", err)
+	}
+}
+
+// initDataDir creates an empty data directory, and the database or repository when the SQLite or git backend is
+// configured. It returns the process exit code.
+func initDataDir(dir datadir.Dir) int {
+	created, err := dir.Init()
+	for _, path := range created {
+		fmt.Println("created ", path)
+	}
+	if err != nil {
+		fmt.Println("failed to initialize data directory: ", err)
+		return 1
+	}
+	cfg := store.ConfigFromEnv(dir)
+	if cfg.Backend == store.BackendSQLite {
+		backend, err := store.Open(cfg)
+		if err != nil {
+			fmt.Println("failed to create database: ", err)
+			return 1
+		}
+		backend.(*store.SQLiteStore).Close()
+		fmt.Println("database ready at ", cfg.SQLitePath)
+	}
+	if cfg.Backend == store.BackendGit {
+		if _, err = store.Open(cfg); err != nil {
+			fmt.Println("failed to create repository: ", err)
+			return 1
+		}
+		fmt.Println("repository ready at ", cfg.Dir)
+	}
+	fmt.Println(fmt.Sprintf(`data directory %s is ready, add images to %s and list them in %s`, dir, dir.Images(), dir.Attribution()))
+	return 0
+}
+
+// showHistory prints every stored version of the listing for a night, and the version each post for the night
+// went out with. It returns the process exit code.
+func showHistory(backend store.Backend, args []string) int {
+	if len(args) != 1 {
+		fmt.Println("usage: city-hall-lights history <YYYY-MM-DD>")
+		return 2
+	}
+	night, err := time.Parse(time.DateOnly, args[0])
+	if err != nil {
+		fmt.Println("invalid night: ", err)
+		return 2
+	}
+	history, err := store.History(backend, night)
+	if err != nil {
+		fmt.Println("failed to read revisions: ", err)
+		return 1
+	}
+	if len(history) == 0 {
+		fmt.Println(fmt.Sprintf(`no revisions recorded for %s, run migrate to record the stored events`, args[0]))
+		return 0
+	}
+	for _, revision := range history {
+		listing := "removed from the schedule"
+		if revision.Event != nil {
+			listing = fmt.Sprintf(`%s | %s | %s`, revision.Event.DateString, revision.Event.Color, revision.Event.Description)
+		}
+		source := ""
+		if revision.SourceHash != "" {
+			source = fmt.Sprintf(` (page %.12s fetched %s)`, revision.SourceHash, revision.FetchedAt.Format(time.RFC3339))
+		}
+		fmt.Println(fmt.Sprintf(`%s %s%s: %s`, revision.RecordedAt.Format(time.RFC3339), revision.Reason, source, listing))
+	}
+
+	posts, err := backend.ListPosts(night)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Println("failed to read posts: ", err)
+		return 1
+	}
+	for _, post := range posts {
+		if !post.Night.Equal(night) || post.ReplyTo != "" {
+			continue
+		}
+		listing := "no listing stored"
+		if event, ok := store.ListingAt(history, night, post.PostedAt); ok {
+			listing = fmt.Sprintf(`%s | %s`, event.Color, event.Description)
+		}
+		fmt.Println(fmt.Sprintf(`posted %s at %s: %s`, post.URI, post.PostedAt.Format(time.RFC3339), listing))
+	}
+	return 0
+}
+
+// overrideEvent changes the stored event lit on a night by hand. It returns the process exit code.
+func overrideEvent(backend store.Backend, args []string) int {
+	flags := flag.NewFlagSet("override", flag.ContinueOnError)
+	color := flags.String("color", "", "color to light City Hall instead")
+	description := flags.String("description", "", "description to post instead")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || (*color == "" && *description == "") {
+		fmt.Println("usage: city-hall-lights override [-color color] [-description text] <YYYY-MM-DD>")
+		return 2
+	}
+	night, err := time.Parse(time.DateOnly, flags.Arg(0))
+	if err != nil {
+		fmt.Println("invalid night: ", err)
+		return 2
+	}
+	event, err := backend.Read(night)
+	if err != nil {
+		fmt.Println(fmt.Sprintf(`failed to read the event lit on %s: %v`, flags.Arg(0), err))
+		return 1
+	}
+	if *color != "" {
+		event.Color = *color
+		event.Colors = nil
+		for _, name := range strings.Split(*color, "/") {
+			event.Colors = append(event.Colors, model.LookupColor(name))
+		}
+	}
+	if *description != "" {
+		event.Description = *description
+	}
+	if err = store.Override(backend.ForMonth(event.StartTimeStamp), event); err != nil {
+		fmt.Println("failed to override event: ", err)
+		return 1
+	}
+	fmt.Println(fmt.Sprintf(`overrode %s: %s | %s`, event.Key(), event.Color, event.Description))
+	return 0
+}
+
+// migrateFiles rewrites the JSON month files in the current schema version. The SQLite backend migrates its
+// schema whenever it is opened. It returns the process exit code.
+func migrateFiles(backend store.Backend) int {
+	files, ok := backend.(interface {
+		Migrate() ([]time.Time, error)
+	})
+	if !ok {
+		fmt.Println("database schema is up to date")
+		return 0
+	}
+	months, err := files.Migrate()
+	for _, month := range months {
+		fmt.Println("migrated ", month.Format("January 2006"))
+	}
+	if err != nil {
+		fmt.Println("failed to migrate month files: ", err)
+		return 1
+	}
+	message := fmt.Sprintf(`migrated %d months to schema version %d`, len(months), store.SchemaVersion)
+	if committer, ok := backend.(store.Committer); ok && len(months) > 0 {
+		if err = committer.Commit(message); err != nil {
+			fmt.Println("failed to commit migrated month files: ", err)
+			return 1
+		}
+	}
+	fmt.Println(message)
+	return 0
+}
+
+// importSchedules backfills the store from saved copies of the lighting schedule page. Months that are
+// already stored are left untouched. It returns the process exit code.
+func importSchedules(backend store.Backend, cfg scraper.Config, path string) int {
+	schedules, errs := scraper.ScrapePath(path, cfg)
+	for _, err := range errs {
+		fmt.Println("skipped page: ", err)
+	}
+	imported := 0
+	for _, schedule := range schedules {
+		month := schedule.Month.Format("January 2006")
+		monthStore := backend.ForMonth(schedule.Month)
+		exists, err := monthStore.HasMonth()
+		if err != nil {
+			fmt.Println("failed to check store: ", err)
+			return 1
+		}
+		if exists {
+			fmt.Println(fmt.Sprintf(`%s already stored, skipping`, month))
+			continue
+		}
+		if len(schedule.Unparsed) > 0 {
+			if err = monthStore.Quarantine(schedule.Unparsed); err != nil {
+				fmt.Println(fmt.Sprintf(`failed to quarantine rejected lines for %s: %v`, month, err))
+				return 1
+			}
+		}
+		if err = store.StoreSchedule(monthStore, model.Source{ScrapedAt: time.Now()}, schedule.Events); err != nil {
+			fmt.Println(fmt.Sprintf(`failed to persist %s: %v`, month, err))
+			return 1
+		}
+		fmt.Println(fmt.Sprintf(`imported %s: %d events, %d rejected lines`, month, len(schedule.Events), len(schedule.Unparsed)))
+		imported++
+	}
+	fmt.Println(fmt.Sprintf(`imported %d of %d months found`, imported, len(schedules)))
+	if len(schedules) == 0 {
+		return 1
+	}
+	return 0
+}
+
+// importJSON copies the JSON month files into the configured backend, e.g. when switching to SQLite. It returns
+// the process exit code.
+func importJSON(from *store.FileStore, backend store.Backend) int {
+	if _, ok := backend.(*store.FileStore); ok {
+		fmt.Println("the file backend already reads the JSON month files, set STORE_BACKEND=sqlite to import them")
+		return 2
+	}
+	months, err := store.ImportFiles(from, backend)
+	for _, month := range months {
+		fmt.Println("imported ", month.Format("January 2006"))
+	}
+	if err != nil {
+		fmt.Println("failed to import JSON month files: ", err)
+		return 1
+	}
+	fmt.Println(fmt.Sprintf(`imported %d months`, len(months)))
+	return 0
+}
+
+// printNext reports when the lights are next on, for days with no lighting.
+func printNext(backend store.Backend, night time.Time) {
+	event, next, err := store.Next(backend, night)
+	if errors.Is(err, store.ErrNotFound) {
+		fmt.Println("no upcoming events stored")
+		return
+	}
+	if err != nil {
+		fmt.Println("failed to find the next event: ", err)
+		return
+	}
+	fmt.Println(fmt.Sprintf(`next event on %s: %s (%s)`, next.Format("Monday, January 2"), event.Description, event.Color))
+}
+
+// listEvents prints the stored events matching the query given by args. It returns the process exit code.
+func listEvents(backend store.Backend, args []string) int {
+	flags := flag.NewFlagSet("events", flag.ContinueOnError)
+	today := time.Now().Format(time.DateOnly)
+	from := flags.String("from", today, "first night, as YYYY-MM-DD")
+	to := flags.String("to", "", "last night, as YYYY-MM-DD (default: the end of the month of -from)")
+	colors := flags.String("color", "", "comma-separated colors, matching events lit in any of them")
+	keyword := flags.String("keyword", "", "text the description or schedule line contains")
+	purpose := flags.String("purpose", "", "recognition, commemoration, celebration or campaign")
+	posted := flags.String("posted", "", "yes for posted events only, no for unposted events only")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	q := store.Query{Keyword: *keyword}
+	var err error
+	if *purpose != "" {
+		if q.Purpose, err = model.ParsePurposeKind(*purpose); err != nil {
+			fmt.Println("invalid -purpose: ", err)
+			return 2
+		}
+	}
+	if q.From, err = time.Parse(time.DateOnly, *from); err != nil {
+		fmt.Println("invalid -from: ", err)
+		return 2
+	}
+	q.To = time.Date(q.From.Year(), q.From.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	if *to != "" {
+		if q.To, err = time.Parse(time.DateOnly, *to); err != nil {
+			fmt.Println("invalid -to: ", err)
+			return 2
+		}
+	}
+	if *colors != "" {
+		q.Colors = strings.Split(*colors, ",")
+	}
+	switch *posted {
+	case "":
+	case "yes":
+		q.Posted = store.Posted
+	case "no":
+		q.Posted = store.Unposted
+	default:
+		fmt.Println(fmt.Sprintf(`invalid -posted %q, want yes or no`, *posted))
+		return 2
+	}
+
+	events, err := store.Find(backend, backend, q)
+	if err != nil {
+		fmt.Println("failed to query events: ", err)
+		return 1
+	}
+	for _, event := range events {
+		fmt.Println(fmt.Sprintf(`%s | %s | %s`, event.DateString, event.Color, event.Description))
+	}
+	fmt.Println(fmt.Sprintf(`%d events from %s through %s`, len(events), q.From.Format(time.DateOnly), q.To.Format(time.DateOnly)))
+	return 0
+}
+
+// exportCalendar writes the events matching the query given by args as an iCalendar file. It returns the
+// process exit code.
+func exportCalendar(backend store.Backend, cfg scraper.Config, args []string) int {
+	flags := flag.NewFlagSet("ics", flag.ContinueOnError)
+	from := flags.String("from", "", "first night, as YYYY-MM-DD (default: a year ago)")
+	to := flags.String("to", "", "last night, as YYYY-MM-DD (default: the end of the schedules published by now)")
+	colors := flags.String("color", "", "comma-separated colors, matching events lit in any of them")
+	keyword := flags.String("keyword", "", "text the description or schedule line contains")
+	purpose := flags.String("purpose", "", "recognition, commemoration, celebration or campaign")
+	output := flags.String("o", "city-hall-lights.ics", "file to write")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	q, err := ical.ParseQuery(url.Values{
+		"from":    {*from},
+		"to":      {*to},
+		"color":   {*colors},
+		"keyword": {*keyword},
+		"purpose": {*purpose},
+	}, time.Now())
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	calendar, err := ical.Build(backend, q, cfg.URL)
+	if err != nil {
+		fmt.Println("failed to build calendar: ", err)
+		return 1
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		fmt.Println("failed to create calendar file: ", err)
+		return 1
+	}
+	if err = calendar.Encode(file); err != nil {
+		file.Close()
+		fmt.Println("failed to write calendar: ", err)
+		return 1
+	}
+	if err = file.Close(); err != nil {
+		fmt.Println("failed to write calendar: ", err)
+		return 1
+	}
+	fmt.Println(fmt.Sprintf(`wrote %d events to %s`, len(calendar.Events), *output))
+	return 0
+}
+
+// serveCalendar serves the calendar feed until the process is stopped. It returns the process exit code.
+func serveCalendar(backend store.Backend, cfg scraper.Config, args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/lights.ics", ical.Handler(backend, cfg.URL))
+	fmt.Println(fmt.Sprintf(`serving the calendar at http://%s/lights.ics`, *addr))
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		fmt.Println("failed to serve calendar: ", err)
+		return 1
+	}
+	return 0
+}
+
 /*
 Example table:
 
@@ -5,68 +5,139 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
+	"city-hall-lights/internal/datadir"
 	"city-hall-lights/internal/model"
 	"city-hall-lights/internal/store"
 	"github.com/bluesky-social/indigo/api/atproto"
 	"github.com/bluesky-social/indigo/api/bsky"
 	"github.com/bluesky-social/indigo/lex/util"
 	"github.com/bluesky-social/indigo/xrpc"
-	"github.com/joho/godotenv"
 	"github.com/tailscale/go-bluesky"
 )
 
-func CreateAndSendPost(event *model.Event) {
-	ctx := context.Background()
-	err := godotenv.Load()
+// CreateAndSendPost posts the event lit on night and records the post in the ledger. Nights the ledger
+// already has a post for are not posted again, and the post's record key is derived from the night, so even
+// without the ledger a retried run overwrites the night's post rather than duplicating it. The post's image is
+// picked from the images of the data directory dir. A post that went out but could not be recorded is returned
+// along with the error.
+func CreateAndSendPost(ledger store.Ledger, dir datadir.Dir, event *model.Event, night time.Time) (model.Post, error) {
+	posted, err := ledger.PostedOn(night)
+	if err != nil {
+		return model.Post{}, err
+	}
+	if len(posted) > 0 {
+		fmt.Println("already posted tonight: ", posted[0].URI)
+		return posted[0], nil
+	}
+	seq, err := nextPostSeq(ledger, night)
+	if err != nil {
+		return model.Post{}, err
+	}
+
+	client, blueskyHandle, err := login()
+	if err != nil {
+		return model.Post{}, err
+	}
+	defer client.Close()
+
+	post, err := publishEvent(client, blueskyHandle, dir, event, night, seq)
 	if err != nil {
-		fmt.Println("Error loading .env file")
+		return model.Post{}, err
+	}
+	if err = ledger.RecordPost(post); err != nil {
+		return post, fmt.Errorf("posted %s but failed to record it in the ledger: %w", post.URI, err)
 	}
+	return post, nil
+}
 
+func login() (*bluesky.Client, string, error) {
+	ctx := context.Background()
 	blueskyHandle := os.Getenv("BLUESKY_IDENTIFIER")
 	blueskyAppkey := os.Getenv("BLUESKY_APP_PASSWORD")
 
 	client, err := bluesky.Dial(ctx, bluesky.ServerBskySocial)
 	if err != nil {
-		panic(err)
+		return nil, "", err
 	}
-	defer client.Close()
 	err = client.Login(ctx, blueskyHandle, blueskyAppkey)
 	switch {
 	case errors.Is(err, bluesky.ErrMasterCredentials):
-		panic("You're not allowed to use your full-access credentials, please create an appkey")
+		err = fmt.Errorf("you're not allowed to use your full-access credentials, please create an appkey: %w", err)
 	case errors.Is(err, bluesky.ErrLoginUnauthorized):
-		panic("Username of application password seems incorrect, please double check")
+		err = fmt.Errorf("username or application password seems incorrect, please double check: %w", err)
 	case err != nil:
-		fmt.Println(err)
-		panic("Something else went wrong, please look at the returned error")
+		err = fmt.Errorf("failed to log in: %w", err)
 	}
-
-	imageMeta, err := store.ReadImageMetadataFromFile("internal/store/images/attribution.json")
 	if err != nil {
-		panic(err)
+		client.Close()
+		return nil, "", err
 	}
+	return client, blueskyHandle, nil
+}
 
-	selectedImage := model.ImageMetadata{}
-	for i, entry := range imageMeta {
-		if entry.FileName == fmt.Sprintf("%s.jpg", event.Color) {
-			selectedImage = imageMeta[i]
-		}
+func publishEvent(client *bluesky.Client, blueskyHandle string, dir datadir.Dir, event *model.Event, night time.Time, seq int) (model.Post, error) {
+	imageMeta, err := store.ReadImageMetadataFromFile(dir.Attribution())
+	if err != nil {
+		return model.Post{}, err
 	}
 
-	blob, err := uploadBlob(client, fmt.Sprintf("internal/store/images/%s", selectedImage.FileName))
+	selectedImage := selectImage(imageMeta, event)
+
+	blob, err := uploadBlob(client, filepath.Join(dir.Images(), selectedImage.FileName))
 	if err != nil {
-		panic(err)
+		return model.Post{}, err
 	}
 	imageEmbed := buildImageEmbed(selectedImage.AltText, blob)
 	post := buildPost(event, imageEmbed)
-	err = sendPost(client, blueskyHandle, post)
+	output, err := sendPost(client, blueskyHandle, postRkey(night, seq), post)
 	if err != nil {
-		panic(err)
+		return model.Post{}, err
 	}
+	return model.Post{
+		EventKey: event.Key(),
+		Night:    night,
+		URI:      output.Uri,
+		CID:      output.Cid,
+		BlobCID:  blob.Ref.String(),
+		PostedAt: time.Now(),
+	}, nil
+}
+
+// selectImage returns the image lit in the same colors as the event, in any order.
+// Events stored before colors were parsed are matched on their raw color string.
+func selectImage(imageMeta []model.ImageMetadata, event *model.Event) model.ImageMetadata {
+	for _, entry := range imageMeta {
+		if len(event.Colors) == 0 {
+			if entry.FileName == fmt.Sprintf("%s.jpg", event.Color) {
+				return entry
+			}
+			continue
+		}
+		if sameColors(entry.Colors, event.Colors) {
+			return entry
+		}
+	}
+	return model.ImageMetadata{}
+}
 
-	return
+func sameColors(names []string, colors []model.Color) bool {
+	if len(names) != len(colors) {
+		return false
+	}
+	remaining := make(map[string]int)
+	for _, name := range names {
+		remaining[name]++
+	}
+	for _, color := range colors {
+		if remaining[color.Name] == 0 {
+			return false
+		}
+		remaining[color.Name]--
+	}
+	return true
 }
 
 func buildImageEmbed(altText string, blob *util.LexBlob) *bsky.FeedPost_Embed {
@@ -119,21 +190,26 @@ func buildPost(event *model.Event, embed *bsky.FeedPost_Embed) *bsky.FeedPost {
 	}
 }
 
-func sendPost(client *bluesky.Client, blueskyHandle string, post *bsky.FeedPost) error {
-	return client.CustomCall(func(c *xrpc.Client) error {
-		input := &atproto.RepoCreateRecord_Input{
+// sendPost writes the post under rkey with putRecord, so that sending it twice replaces the first copy.
+func sendPost(client *bluesky.Client, blueskyHandle string, rkey string, post *bsky.FeedPost) (*atproto.RepoPutRecord_Output, error) {
+	var output *atproto.RepoPutRecord_Output
+	err := client.CustomCall(func(c *xrpc.Client) error {
+		input := &atproto.RepoPutRecord_Input{
 			Collection: "app.bsky.feed.post",
 			Record: &util.LexiconTypeDecoder{
 				Val: post,
 			},
 			Repo: blueskyHandle,
+			Rkey: rkey,
 		}
-		_, err := atproto.RepoCreateRecord(context.Background(), c, input)
-		if err != nil {
-			return err
-		}
-		return nil
+		var err error
+		output, err = atproto.RepoPutRecord(context.Background(), c, input)
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func createImageEmbed(client *bluesky.Client, blueskyHandle string, post *bsky.FeedPost) error {
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenLedger fails every read and write.
+type brokenLedger struct{}
+
+var errLedger = errors.New("ledger unavailable")
+
+func (brokenLedger) RecordPost(model.Post) error               { return errLedger }
+func (brokenLedger) ListPosts(time.Time) ([]model.Post, error) { return nil, errLedger }
+func (brokenLedger) PostedOn(time.Time) ([]model.Post, error)  { return nil, errLedger }
+
+func TestCreateAndSendPost_ledgerError(t *testing.T) {
+	night := time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC)
+	_, err := CreateAndSendPost(brokenLedger{}, "", &model.Event{StartTimeStamp: night}, night)
+	require.ErrorIs(t, err, errLedger)
+}
+
+func Test_selectImage(t *testing.T) {
+	imageMeta := []model.ImageMetadata{
+		{FileName: "blue-pink-white.jpg", Colors: []string{"blue", "pink", "white"}},
+		{FileName: "orange.jpg", Colors: []string{"orange"}},
+		{FileName: "shades-of-amber.jpg", Colors: []string{"amber"}},
+	}
+	type args struct {
+		event *model.Event
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "matches colors in any order",
+			args: args{
+				event: &model.Event{
+					Color: "pink/blue/white",
+					Colors: []model.Color{
+						model.LookupColor("pink"),
+						model.LookupColor("blue"),
+						model.LookupColor("white"),
+					},
+				},
+			},
+			want: "blue-pink-white.jpg",
+		},
+		{
+			name: "matches synonyms by their canonical color",
+			args: args{
+				event: &model.Event{
+					Color:  "shades of amber",
+					Colors: []model.Color{model.LookupColor("shades of amber")},
+				},
+			},
+			want: "shades-of-amber.jpg",
+		},
+		{
+			name: "does not match a subset of the colors",
+			args: args{
+				event: &model.Event{
+					Color:  "orange/gold",
+					Colors: []model.Color{model.LookupColor("orange"), model.LookupColor("gold")},
+				},
+			},
+			want: "",
+		},
+		{
+			name: "matches events without parsed colors on the raw color string",
+			args: args{
+				event: &model.Event{
+					Color: "orange",
+				},
+			},
+			want: "orange.jpg",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectImage(imageMeta, tt.args.event)
+			require.Equal(t, tt.want, got.FileName)
+		})
+	}
+}
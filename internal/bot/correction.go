@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"city-hall-lights/internal/datadir"
+	"city-hall-lights/internal/model"
+	"city-hall-lights/internal/store"
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/tailscale/go-bluesky"
+)
+
+// lightsOn is when City Hall's lights come on in the evening. A correction arriving before then can replace
+// the night's post, since few followers will have acted on it yet.
+const lightsOn = 17 * time.Hour
+
+// Correction follows up on a post whose event changed or was cancelled after it was published.
+type Correction struct {
+	Post model.Post
+	// Event is what is now lit on the post's night, or nil when the lighting was cancelled.
+	Event *model.Event
+	Text  string
+	// Repost deletes the original post and publishes the corrected event in its place instead of replying.
+	Repost bool
+}
+
+// PlanCorrections returns a correction for every live post whose event was changed by changes. When repost is
+// set, posts whose night has not started yet are replaced rather than replied to.
+func PlanCorrections(posts []model.Post, changes []model.Change, now time.Time, repost bool) []Correction {
+	corrections := []Correction{}
+	for _, post := range posts {
+		if post.Deleted || post.ReplyTo != "" {
+			continue
+		}
+		changed := false
+		var before *model.Event
+		for _, change := range changes {
+			if change.Key == post.EventKey && change.Kind != model.ChangeAdded {
+				changed = true
+				before = change.Before
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		// the night may now be lit for another event, e.g. when a listing was replaced by a new one
+		var after *model.Event
+		for _, change := range changes {
+			if change.After == nil || !store.IsLitOn(*change.After, post.Night) {
+				continue
+			}
+			if change.Key == post.EventKey {
+				after = change.After
+				break
+			}
+			if after == nil {
+				after = change.After
+			}
+		}
+
+		correction := Correction{Post: post, Event: after}
+		if after == nil {
+			correction.Text = fmt.Sprintf("Update: City Hall will no longer be lit %s tonight.", describe(*before))
+		} else {
+			correction.Text = fmt.Sprintf("Update: City Hall will now be lit %s.", describe(*after))
+			correction.Repost = repost && now.Before(lightsOnAt(post.Night))
+		}
+		corrections = append(corrections, correction)
+	}
+	return corrections
+}
+
+// purposePhrases introduce the occasion of an event by its purpose.
+var purposePhrases = map[model.PurposeKind]string{
+	model.PurposeRecognition:   "in recognition of",
+	model.PurposeCommemoration: "in commemoration of",
+	model.PurposeCelebration:   "in celebration of",
+	model.PurposeCampaign:      "in support of",
+}
+
+// describe names the colors of an event and what they are for, e.g. "purple in recognition of World
+// Prematurity Day". The stored description can't be used, since it is already a sentence.
+func describe(event model.Event) string {
+	text := colorNames(event)
+	occasion := event.Purpose.Occasion
+	if occasion == "" {
+		return text
+	}
+	if honoree := event.Purpose.Honoree; honoree != "" {
+		possessive := "’s"
+		if strings.HasSuffix(honoree, "s") {
+			possessive = "’"
+		}
+		occasion = honoree + possessive + " " + occasion
+	}
+	if phrase, ok := purposePhrases[event.Purpose.Kind]; ok {
+		occasion = phrase + " " + occasion
+	}
+	return text + " " + occasion
+}
+
+// colorNames lists the colors of an event, e.g. "red, white and blue".
+func colorNames(event model.Event) string {
+	names := []string{}
+	for _, color := range event.Colors {
+		names = append(names, strings.ToLower(color.Name))
+	}
+	switch len(names) {
+	case 0:
+		return strings.ToLower(event.Color)
+	case 1:
+		return names[0]
+	default:
+		return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+	}
+}
+
+func lightsOnAt(night time.Time) time.Time {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		location = time.UTC
+	}
+	return time.Date(night.Year(), night.Month(), night.Day(), 0, 0, 0, 0, location).Add(lightsOn)
+}
+
+// SendCorrections publishes the corrections and records in the ledger each reply or replacement post, and
+// every original that was deleted. Replacement posts pick their image from the data directory dir.
+func SendCorrections(ledger store.Ledger, dir datadir.Dir, corrections []Correction) error {
+	if len(corrections) == 0 {
+		return nil
+	}
+	client, blueskyHandle, err := login()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	for _, correction := range corrections {
+		seq, err := nextPostSeq(ledger, correction.Post.Night)
+		if err != nil {
+			return err
+		}
+		if correction.Repost {
+			if err = deletePost(client, blueskyHandle, correction.Post.URI); err != nil {
+				return err
+			}
+			deleted := correction.Post
+			deleted.Deleted = true
+			if err = ledger.RecordPost(deleted); err != nil {
+				return err
+			}
+
+			post, err := publishEvent(client, blueskyHandle, dir, correction.Event, correction.Post.Night, seq)
+			if err != nil {
+				return err
+			}
+			if err = ledger.RecordPost(post); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ref := &atproto.RepoStrongRef{Uri: correction.Post.URI, Cid: correction.Post.CID}
+		output, err := sendPost(client, blueskyHandle, postRkey(correction.Post.Night, seq), &bsky.FeedPost{
+			Text:      correction.Text,
+			CreatedAt: time.Now().Local().Format(time.RFC3339),
+			Reply:     &bsky.FeedPost_ReplyRef{Root: ref, Parent: ref},
+		})
+		if err != nil {
+			return err
+		}
+		post := model.Post{
+			EventKey: correction.Post.EventKey,
+			Night:    correction.Post.Night,
+			URI:      output.Uri,
+			CID:      output.Cid,
+			PostedAt: time.Now(),
+			ReplyTo:  correction.Post.URI,
+		}
+		if correction.Event != nil {
+			post.EventKey = correction.Event.Key()
+		}
+		if err = ledger.RecordPost(post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deletePost removes a post by its AT-URI, at://<repo>/<collection>/<rkey>.
+func deletePost(client *bluesky.Client, blueskyHandle string, uri string) error {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid post uri %q", uri)
+	}
+	return client.CustomCall(func(c *xrpc.Client) error {
+		return atproto.RepoDeleteRecord(context.Background(), c, &atproto.RepoDeleteRecord_Input{
+			Collection: parts[1],
+			Repo:       blueskyHandle,
+			Rkey:       parts[2],
+		})
+	})
+}
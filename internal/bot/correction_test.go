@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"city-hall-lights/internal/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func parseEvent(t *testing.T, line string) model.Event {
+	event, err := parser.ParseEvent(line, time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	return event
+}
+
+func TestPlanCorrections(t *testing.T) {
+	night := time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC)
+	teal := parseEvent(t, "Wednesday, November 6, 2024 – Teal – in recognition of the Alzheimer Foundations’ annual “Light the World Teal” Campaign")
+	purple := parseEvent(t, "Wednesday, November 6, 2024 – Purple – in recognition of the Alzheimer Foundations’ annual “Light the World Teal” Campaign")
+	orange := parseEvent(t, "Wednesday, November 6, 2024 – Orange – in celebration of Diwali")
+	second := parseEvent(t, "Wednesday, November 6, 2024 – Gold – in recognition of the Hospitality Industry")
+	second.Seq = 1
+	post := model.Post{EventKey: teal.Key(), Night: night, URI: "at://did:plc:abc/app.bsky.feed.post/3k", CID: "bafy"}
+	morning := time.Date(2024, 11, 6, 9, 0, 0, 0, time.UTC)
+	evening := time.Date(2024, 11, 7, 3, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		posts   []model.Post
+		changes []model.Change
+		now     time.Time
+		repost  bool
+		want    []Correction
+	}{
+		{
+			name:  "recolored event gets a reply",
+			posts: []model.Post{post},
+			changes: []model.Change{
+				{Kind: model.ChangeRecolored, Key: teal.Key(), Before: &teal, After: &purple},
+			},
+			now: morning,
+			want: []Correction{{
+				Post:  post,
+				Event: &purple,
+				Text:  `Update: City Hall will now be lit purple in recognition of Alzheimer Foundations’ "Light the World Teal" Campaign.`,
+			}},
+		},
+		{
+			name:  "cancelled event gets a reply",
+			posts: []model.Post{post},
+			changes: []model.Change{
+				{Kind: model.ChangeRemoved, Key: teal.Key(), Before: &teal},
+			},
+			now:    morning,
+			repost: true,
+			want: []Correction{{
+				Post: post,
+				Text: `Update: City Hall will no longer be lit teal in recognition of Alzheimer Foundations’ "Light the World Teal" Campaign tonight.`,
+			}},
+		},
+		{
+			name:  "replaced event is reposted before the lights come on",
+			posts: []model.Post{post},
+			changes: []model.Change{
+				{Kind: model.ChangeRemoved, Key: teal.Key(), Before: &teal},
+				{Kind: model.ChangeAdded, Key: orange.Key(), After: &orange},
+			},
+			now:    morning,
+			repost: true,
+			want: []Correction{{
+				Post:   post,
+				Event:  &orange,
+				Text:   "Update: City Hall will now be lit orange in celebration of Diwali.",
+				Repost: true,
+			}},
+		},
+		{
+			name:  "replaced event gets a reply once the lights are on",
+			posts: []model.Post{post},
+			changes: []model.Change{
+				{Kind: model.ChangeRecolored, Key: teal.Key(), Before: &teal, After: &purple},
+			},
+			now:    evening,
+			repost: true,
+			want: []Correction{{
+				Post:  post,
+				Event: &purple,
+				Text:  `Update: City Hall will now be lit purple in recognition of Alzheimer Foundations’ "Light the World Teal" Campaign.`,
+			}},
+		},
+		{
+			name:  "the post's own event is preferred over another lit the same night",
+			posts: []model.Post{post},
+			changes: []model.Change{
+				{Kind: model.ChangeAdded, Key: second.Key(), After: &second},
+				{Kind: model.ChangeRecolored, Key: teal.Key(), Before: &teal, After: &purple},
+			},
+			now: morning,
+			want: []Correction{{
+				Post:  post,
+				Event: &purple,
+				Text:  `Update: City Hall will now be lit purple in recognition of Alzheimer Foundations’ "Light the World Teal" Campaign.`,
+			}},
+		},
+		{
+			name:  "corrections and deleted posts are not corrected again",
+			posts: []model.Post{{EventKey: teal.Key(), Night: night, ReplyTo: post.URI}, {EventKey: teal.Key(), Night: night, Deleted: true}},
+			changes: []model.Change{
+				{Kind: model.ChangeRecolored, Key: teal.Key(), Before: &teal, After: &purple},
+			},
+			now:  morning,
+			want: []Correction{},
+		},
+		{
+			name:  "changes to other events are ignored",
+			posts: []model.Post{post},
+			changes: []model.Change{
+				{Kind: model.ChangeAdded, Key: "2024-11-09", After: &model.Event{StartTimeStamp: time.Date(2024, 11, 9, 0, 0, 0, 0, time.UTC)}},
+			},
+			now:  morning,
+			want: []Correction{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, PlanCorrections(tt.posts, tt.changes, tt.now, tt.repost))
+		})
+	}
+}
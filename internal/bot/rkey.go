@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"os"
+	"time"
+
+	"city-hall-lights/internal/store"
+)
+
+// tidAlphabet is the base32-sortable alphabet of atproto timestamp identifiers.
+const tidAlphabet = "234567abcdefghijklmnopqrstuvwxyz"
+
+// postRkey returns the record key of the seq'th post made for night. Posts are keyed by TIDs, which pack
+// microseconds since the epoch with a 10-bit clock identifier; using the night's midnight and seq makes the
+// key the same on every run, so a post can't be created twice.
+func postRkey(night time.Time, seq int) string {
+	midnight := time.Date(night.Year(), night.Month(), night.Day(), 0, 0, 0, 0, time.UTC)
+	v := uint64(midnight.UnixMicro())<<10 | uint64(seq&0x3ff)
+	rkey := make([]byte, 13)
+	for i := len(rkey) - 1; i >= 0; i-- {
+		rkey[i] = tidAlphabet[v&0x1f]
+		v >>= 5
+	}
+	return string(rkey)
+}
+
+// nextPostSeq returns how many posts, including replies and deleted ones, the ledger has for night.
+func nextPostSeq(ledger store.Ledger, night time.Time) (int, error) {
+	posts, err := ledger.ListPosts(night)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	seq := 0
+	for _, post := range posts {
+		if post.Night.Equal(night) {
+			seq++
+		}
+	}
+	return seq, nil
+}
@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"city-hall-lights/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postRkey(t *testing.T) {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		night time.Time
+		seq   int
+		want  string
+	}{
+		{
+			name:  "first post of the night",
+			night: time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC),
+			want:  "3laagtiys2222",
+		},
+		{
+			name:  "same night in another location",
+			night: time.Date(2024, 11, 6, 0, 0, 0, 0, location),
+			want:  "3laagtiys2222",
+		},
+		{
+			name:  "correction on the same night",
+			night: time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC),
+			seq:   1,
+			want:  "3laagtiys2223",
+		},
+		{
+			name:  "next night",
+			night: time.Date(2024, 11, 7, 0, 0, 0, 0, time.UTC),
+			want:  "3lacxcghk2222",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, postRkey(tt.night, tt.seq))
+		})
+	}
+}
+
+func Test_nextPostSeq(t *testing.T) {
+	ledger := store.NewFileStore().In(t.TempDir())
+	night := time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC)
+
+	seq, err := nextPostSeq(&ledger, night)
+	require.NoError(t, err)
+	require.Equal(t, 0, seq)
+
+	require.NoError(t, ledger.RecordPost(model.Post{Night: night, URI: "at://did:plc:abc/app.bsky.feed.post/3laagtiys2222"}))
+	require.NoError(t, ledger.RecordPost(model.Post{Night: night.AddDate(0, 0, 1), URI: "at://did:plc:abc/app.bsky.feed.post/3lacxcghk2222"}))
+	seq, err = nextPostSeq(&ledger, night)
+	require.NoError(t, err)
+	require.Equal(t, 1, seq)
+}
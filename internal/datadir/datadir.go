@@ -0,0 +1,114 @@
+// Package datadir locates the directory the bot keeps its data in, so that it can run from anywhere rather than
+// only from the repository root.
+package datadir
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// EnvVar sets the data directory when no flag does.
+const EnvVar = "CITY_HALL_LIGHTS_DATA_DIR"
+
+// Legacy is where the bot kept its data, relative to the repository root, before the data directory could be
+// chosen.
+const Legacy Dir = "internal/store"
+
+// attributionFile lists the images posts can be illustrated with, their alt text and credits.
+const attributionFile = "attribution.json"
+
+// Dir is a data directory, laid out as:
+//
+//	events/     JSON month files, with the ledger, changes, quarantine and scrape runs beneath
+//	events.db   the SQLite database, when that backend is used
+//	images/     attribution.json and the images it lists
+//	cache/      the last response of the schedule page
+//
+// The repository's internal/store directory follows the same layout.
+type Dir string
+
+// Resolve returns the data directory named by flag, else by the environment, else the default.
+func Resolve(flag string) Dir {
+	if flag != "" {
+		return Dir(flag)
+	}
+	if dir := os.Getenv(EnvVar); dir != "" {
+		return Dir(dir)
+	}
+	return Default()
+}
+
+// Default returns city-hall-lights under $XDG_DATA_HOME, or under ~/.local/share when that isn't set. It falls
+// back to a relative city-hall-lights directory when there is no home directory either.
+func Default() Dir {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+		return Dir(filepath.Join(dataHome, "city-hall-lights"))
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Dir("city-hall-lights")
+	}
+	return Dir(filepath.Join(home, ".local", "share", "city-hall-lights"))
+}
+
+// HasEvents reports whether any month's schedule has been stored in the data directory, by either backend.
+func (d Dir) HasEvents() bool {
+	months, _ := filepath.Glob(filepath.Join(d.Events(), "*.json"))
+	_, err := os.Stat(d.SQLite())
+	return len(months) > 0 || err == nil
+}
+
+func (d Dir) Events() string {
+	return filepath.Join(string(d), "events")
+}
+
+func (d Dir) SQLite() string {
+	return filepath.Join(string(d), "events.db")
+}
+
+func (d Dir) Images() string {
+	return filepath.Join(string(d), "images")
+}
+
+func (d Dir) Attribution() string {
+	return filepath.Join(d.Images(), attributionFile)
+}
+
+func (d Dir) Cache() string {
+	return filepath.Join(string(d), "cache")
+}
+
+// Init creates the directories of an empty data directory and an empty image list, leaving anything already
+// there untouched. It returns the paths it created.
+func (d Dir) Init() ([]string, error) {
+	created := []string{}
+	for _, dir := range []string{string(d), d.Events(), d.Images(), d.Cache()} {
+		_, err := os.Stat(dir)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return created, err
+		}
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return created, err
+		}
+		created = append(created, dir)
+	}
+	file, err := os.OpenFile(d.Attribution(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return created, nil
+	}
+	if err != nil {
+		return created, err
+	}
+	if _, err = file.WriteString("[]\n"); err != nil {
+		file.Close()
+		return created, err
+	}
+	if err = file.Close(); err != nil {
+		return created, err
+	}
+	return append(created, d.Attribution()), nil
+}
@@ -0,0 +1,78 @@
+package datadir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name string
+		flag string
+		env  string
+		xdg  string
+		want Dir
+	}{
+		{
+			name: "flag wins",
+			flag: "/srv/flag",
+			env:  "/srv/env",
+			want: "/srv/flag",
+		},
+		{
+			name: "environment",
+			env:  "/srv/env",
+			xdg:  "/srv/xdg",
+			want: "/srv/env",
+		},
+		{
+			name: "XDG data home",
+			xdg:  "/srv/xdg",
+			want: "/srv/xdg/city-hall-lights",
+		},
+		{
+			name: "relative XDG data home is ignored",
+			xdg:  "relative",
+			want: "/home/lights/.local/share/city-hall-lights",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvVar, tt.env)
+			t.Setenv("XDG_DATA_HOME", tt.xdg)
+			t.Setenv("HOME", "/home/lights")
+			require.Equal(t, tt.want, Resolve(tt.flag))
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	d := Dir(filepath.Join(t.TempDir(), "data"))
+	created, err := d.Init()
+	require.NoError(t, err)
+	require.Equal(t, []string{string(d), d.Events(), d.Images(), d.Cache(), d.Attribution()}, created)
+	attribution, err := os.ReadFile(d.Attribution())
+	require.NoError(t, err)
+	require.Equal(t, "[]\n", string(attribution))
+
+	require.NoError(t, os.WriteFile(d.Attribution(), []byte(`[{"fileName":"teal.jpg"}]`), 0644))
+	created, err = d.Init()
+	require.NoError(t, err)
+	require.Empty(t, created)
+	attribution, err = os.ReadFile(d.Attribution())
+	require.NoError(t, err)
+	require.Equal(t, `[{"fileName":"teal.jpg"}]`, string(attribution))
+}
+
+func TestHasEvents(t *testing.T) {
+	d := Dir(t.TempDir())
+	_, err := d.Init()
+	require.NoError(t, err)
+	require.False(t, d.HasEvents())
+
+	require.NoError(t, os.WriteFile(filepath.Join(d.Events(), "2024-11-01.json"), []byte("[]"), 0644))
+	require.True(t, d.HasEvents())
+}
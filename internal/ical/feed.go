@@ -0,0 +1,78 @@
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"city-hall-lights/internal/store"
+)
+
+// Handler serves the calendar of the events in b as a feed calendar clients can subscribe to, linking to the
+// schedule page at scheduleURL. The query parameters color (repeated or comma-separated), keyword, purpose,
+// and from and to as YYYY-MM-DD narrow it, e.g. /lights.ics?color=purple,teal.
+func Handler(b store.Backend, scheduleURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		q, err := ParseQuery(r.URL.Query(), time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		calendar, err := Build(b, q, scheduleURL)
+		if err != nil {
+			fmt.Println("failed to build calendar: ", err)
+			http.Error(w, "failed to read the schedule", http.StatusInternalServerError)
+			return
+		}
+		var body bytes.Buffer
+		if err = calendar.Encode(&body); err != nil {
+			http.Error(w, "failed to encode the calendar", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="city-hall-lights.ics"`)
+		w.Write(body.Bytes())
+	})
+}
+
+// ParseQuery reads a calendar query from URL query parameters, defaulting to DefaultQuery.
+func ParseQuery(values url.Values, now time.Time) (store.Query, error) {
+	q := DefaultQuery(now)
+	var err error
+	if from := values.Get("from"); from != "" {
+		if q.From, err = time.Parse(time.DateOnly, from); err != nil {
+			return store.Query{}, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if to := values.Get("to"); to != "" {
+		if q.To, err = time.Parse(time.DateOnly, to); err != nil {
+			return store.Query{}, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if q.To.Before(q.From) {
+		return store.Query{}, fmt.Errorf("to is before from")
+	}
+	for _, color := range values["color"] {
+		for _, name := range strings.Split(color, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				q.Colors = append(q.Colors, name)
+			}
+		}
+	}
+	q.Keyword = values.Get("keyword")
+	if purpose := values.Get("purpose"); purpose != "" {
+		if q.Purpose, err = model.ParsePurposeKind(purpose); err != nil {
+			return store.Query{}, err
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,90 @@
+package ical
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"city-hall-lights/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	fs := store.NewFileStore().In(t.TempDir()).At(day(11, 1))
+	require.NoError(t, store.StoreSchedule(&fs, model.Source{}, november()))
+	server := httptest.NewServer(Handler(&fs, "https://www.sf.gov/location/san-francisco-city-hall"))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantUIDs   int
+	}{
+		{
+			name:       "every night",
+			query:      "from=2024-11-01&to=2024-11-30",
+			wantStatus: http.StatusOK,
+			wantUIDs:   4,
+		},
+		{
+			name:       "by color",
+			query:      "from=2024-11-01&to=2024-11-30&color=blue,purple",
+			wantStatus: http.StatusOK,
+			wantUIDs:   1,
+		},
+		{
+			name:       "by keyword",
+			query:      "from=2024-11-01&to=2024-11-30&keyword=thanksgiving",
+			wantStatus: http.StatusOK,
+			wantUIDs:   3,
+		},
+		{
+			name:       "invalid purpose",
+			query:      "purpose=holiday",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid date",
+			query:      "from=November",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + "/lights.ics?" + tt.query)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			require.Equal(t, "text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantUIDs, strings.Count(string(body), "\r\nUID:"))
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	now := time.Date(2024, 11, 15, 12, 0, 0, 0, time.UTC)
+	q, err := ParseQuery(url.Values{"color": {"purple, teal", "gold"}, "purpose": {"campaign"}}, now)
+	require.NoError(t, err)
+	require.Equal(t, store.Query{
+		From:    time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC),
+		Colors:  []string{"purple", "teal", "gold"},
+		Purpose: model.PurposeCampaign,
+	}, q)
+
+	_, err = ParseQuery(url.Values{"from": {"2024-12-01"}, "to": {"2024-11-01"}}, now)
+	require.Error(t, err)
+	_, err = ParseQuery(url.Values{"purpose": {"campagin"}}, now)
+	require.Error(t, err)
+}
@@ -0,0 +1,205 @@
+// Package ical renders the lighting schedule as an RFC 5545 calendar that can be exported or subscribed to.
+package ical
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"city-hall-lights/internal/model"
+	"city-hall-lights/internal/store"
+)
+
+const (
+	prodID = "-//city-hall-lights//SF City Hall lighting//EN"
+	// uidDomain makes UIDs globally unique, as RFC 5545 asks.
+	uidDomain = "city-hall-lights"
+	// maxLine is the longest a content line may be, in octets, before it is folded.
+	maxLine = 75
+	// monthsBack and monthsAhead bound the nights a calendar covers by default. Schedules are published a
+	// month at a time, so nothing further ahead is known.
+	monthsBack  = 12
+	monthsAhead = 3
+)
+
+// Calendar is the lighting of City Hall over a range of nights.
+type Calendar struct {
+	Name   string
+	URL    string
+	From   time.Time
+	To     time.Time
+	Events []model.Event
+	// Sequences maps event keys to the number of times the event was revised after it was first stored.
+	Sequences map[string]int
+	// Stamp is when the calendar was generated.
+	Stamp time.Time
+}
+
+// DefaultQuery selects the nights of the last year and of the schedules that can have been published by now.
+func DefaultQuery(now time.Time) store.Query {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return store.Query{
+		From: month.AddDate(0, -monthsBack, 0),
+		To:   month.AddDate(0, monthsAhead+1, -1),
+	}
+}
+
+// Build returns the calendar of the events matching q, linking to the schedule page at url. Each event's
+// SEQUENCE is the number of revisions recorded for it after the first, so that calendar clients pick up a
+// changed listing.
+func Build(b store.Backend, q store.Query, url string) (Calendar, error) {
+	events, err := store.Find(b, b, q)
+	if err != nil {
+		return Calendar{}, err
+	}
+	sequences, err := revisionCounts(b, q.From, q.To)
+	if err != nil {
+		return Calendar{}, err
+	}
+	return Calendar{
+		Name:      "SF City Hall lighting",
+		URL:       url,
+		From:      q.From,
+		To:        q.To,
+		Events:    events,
+		Sequences: sequences,
+		Stamp:     time.Now(),
+	}, nil
+}
+
+// revisionCounts returns the number of revisions after the first of each event stored for the months from the
+// month before from through the month after to.
+func revisionCounts(b store.Backend, from, to time.Time) (map[string]int, error) {
+	counts := make(map[string]int)
+	first := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	last := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		revisions, err := b.ListRevisions(m)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, revision := range revisions {
+			counts[revision.Key]++
+		}
+	}
+	sequences := make(map[string]int)
+	for key, count := range counts {
+		sequences[key] = count - 1
+	}
+	return sequences, nil
+}
+
+// Encode writes the calendar in the iCalendar format, with one all-day VEVENT for each night an event is lit
+// from c.From through c.To.
+func (c Calendar) Encode(w io.Writer) error {
+	e := &encoder{w: w}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", prodID)
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	e.line("X-WR-CALNAME", escape(c.Name))
+	stamp := c.Stamp.UTC().Format("20060102T150405Z")
+	for _, event := range c.Events {
+		for i, night := range store.Nights(event) {
+			if night.Before(c.From) || night.After(c.To) {
+				continue
+			}
+			e.line("BEGIN", "VEVENT")
+			// nights are numbered rather than dated so that a rescheduled night updates its entry
+			e.line("UID", fmt.Sprintf(`%s-%d@%s`, event.Key(), i+1, uidDomain))
+			e.line("DTSTAMP", stamp)
+			e.line("DTSTART;VALUE=DATE", night.Format("20060102"))
+			e.line("DTEND;VALUE=DATE", night.AddDate(0, 0, 1).Format("20060102"))
+			e.line("SEQUENCE", fmt.Sprint(c.Sequences[event.Key()]))
+			e.line("SUMMARY", escape(summary(event)))
+			e.line("DESCRIPTION", escape(description(event)))
+			if c.URL != "" {
+				e.line("URL", c.URL)
+			}
+			e.line("TRANSP", "TRANSPARENT")
+			e.line("END", "VEVENT")
+		}
+	}
+	e.line("END", "VCALENDAR")
+	return e.err
+}
+
+// summary names the colors City Hall is lit and what for, e.g. "City Hall lit orange and gold (recognition)".
+func summary(event model.Event) string {
+	text := "City Hall lit " + colors(event)
+	if event.Purpose.Kind != "" {
+		text += fmt.Sprintf(` (%s)`, event.Purpose.Kind)
+	}
+	return text
+}
+
+func colors(event model.Event) string {
+	names := []string{}
+	for _, color := range event.Colors {
+		names = append(names, color.Name)
+	}
+	switch len(names) {
+	case 0:
+		return event.Color
+	case 1:
+		return names[0]
+	default:
+		return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+	}
+}
+
+func description(event model.Event) string {
+	lines := []string{event.Description}
+	if event.Purpose.Honoree != "" {
+		lines = append(lines, "Honoring: "+event.Purpose.Honoree)
+	}
+	if event.Purpose.Occasion != "" {
+		lines = append(lines, "Occasion: "+event.Purpose.Occasion)
+	}
+	if event.RawEventString != "" {
+		lines = append(lines, "Schedule: "+event.RawEventString)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escape escapes a TEXT value.
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+type encoder struct {
+	w   io.Writer
+	err error
+}
+
+// line writes a content line, folded so that no line is longer than maxLine octets without splitting a UTF-8
+// character, and ended with CRLF.
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	content := name + ":" + value
+	var b strings.Builder
+	width := maxLine
+	for len(content) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		// continuation lines start with a space, which counts towards their length
+		width = maxLine - 1
+	}
+	b.WriteString(content)
+	b.WriteString("\r\n")
+	_, e.err = io.WriteString(e.w, b.String())
+}
@@ -0,0 +1,112 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"city-hall-lights/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func november() []model.Event {
+	return []model.Event{
+		{
+			DateString:     "Tuesday, November 5, 2024",
+			StartTimeStamp: day(11, 5),
+			Color:          "red/white/blue",
+			Colors:         []model.Color{model.LookupColor("red"), model.LookupColor("white"), model.LookupColor("blue")},
+			Description:    "Tonight City Hall will be red, white, and blue in recognition of Election Day 2024",
+			Purpose:        model.Purpose{Kind: model.PurposeRecognition, Occasion: "Election Day 2024"},
+			RawEventString: "Tuesday, November 5, 2024 – red/white/blue – in recognition of Election Day 2024",
+		},
+		{
+			DateString:     "Thursday, November 28 through Saturday, November 30, 2024",
+			StartTimeStamp: day(11, 28),
+			EndTimeStamp:   day(11, 30),
+			Color:          "shades of amber",
+			Description:    "Tonight City Hall will be shades of amber in recognition of the Thanksgiving Holiday",
+		},
+	}
+}
+
+func unfold(ics string) []string {
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(ics, "\r\n ", ""), "\r\n"), "\r\n")
+}
+
+func TestCalendar_Encode(t *testing.T) {
+	c := Calendar{
+		Name:      "SF City Hall lighting",
+		URL:       "https://www.sf.gov/location/san-francisco-city-hall",
+		From:      day(11, 1),
+		To:        day(11, 29),
+		Events:    november(),
+		Sequences: map[string]int{"2024-11-28": 2},
+		Stamp:     time.Date(2024, 11, 1, 8, 30, 0, 0, time.UTC),
+	}
+	var out bytes.Buffer
+	require.NoError(t, c.Encode(&out))
+	ics := out.String()
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		require.LessOrEqual(t, len(line), maxLine)
+		require.True(t, strings.ToValidUTF8(line, "?") == line, "folded inside a character: %q", line)
+	}
+	lines := unfold(ics)
+	require.Equal(t, "BEGIN:VCALENDAR", lines[0])
+	require.Equal(t, "END:VCALENDAR", lines[len(lines)-1])
+	require.Equal(t, []string{
+		"BEGIN:VEVENT",
+		"UID:2024-11-05-1@city-hall-lights",
+		"DTSTAMP:20241101T083000Z",
+		"DTSTART;VALUE=DATE:20241105",
+		"DTEND;VALUE=DATE:20241106",
+		"SEQUENCE:0",
+		`SUMMARY:City Hall lit red\, white and blue (recognition)`,
+		`DESCRIPTION:Tonight City Hall will be red\, white\, and blue in recognition of Election Day 2024\nOccasion: Election Day 2024\nSchedule: Tuesday\, November 5\, 2024 – red/white/blue – in recognition of Election Day 2024`,
+		"URL:https://www.sf.gov/location/san-francisco-city-hall",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+	}, lines[6:17])
+
+	uids := []string{}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "UID:") {
+			uids = append(uids, line)
+		}
+		if strings.HasPrefix(line, "SEQUENCE:") && line != "SEQUENCE:0" {
+			require.Equal(t, "SEQUENCE:2", line)
+		}
+	}
+	// the night of November 30 is after To
+	require.Equal(t, []string{
+		"UID:2024-11-05-1@city-hall-lights",
+		"UID:2024-11-28-1@city-hall-lights",
+		"UID:2024-11-28-2@city-hall-lights",
+	}, uids)
+}
+
+func TestEscape(t *testing.T) {
+	require.Equal(t, `a\\b\;c\,d\ne`, escape("a\\b;c,d\ne"))
+}
+
+func TestBuild(t *testing.T) {
+	fs := store.NewFileStore().In(t.TempDir()).At(day(11, 1))
+	source := model.Source{ScrapedAt: day(11, 1), Hash: "abc123"}
+	require.NoError(t, store.StoreSchedule(&fs, source, november()))
+	scraped := november()
+	scraped[1].Color = "orange"
+	_, err := store.Reconcile(&fs, source, scraped)
+	require.NoError(t, err)
+
+	c, err := Build(&fs, store.Query{From: day(11, 1), To: day(11, 30), Colors: []string{"orange"}}, "https://www.sf.gov/location/san-francisco-city-hall")
+	require.NoError(t, err)
+	require.Len(t, c.Events, 1)
+	require.Equal(t, map[string]int{"2024-11-05": 0, "2024-11-28": 1}, c.Sequences)
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+)
+
+// Color is a canonical lighting color. Colors missing from the palette keep the name they were listed with
+// and are flagged as unknown.
+type Color struct {
+	Name    string `json:"name"`
+	Hex     string `json:"hex,omitempty"`
+	Emoji   string `json:"emoji,omitempty"`
+	Unknown bool   `json:"unknown,omitempty"`
+}
+
+type paletteEntry struct {
+	Color
+	Synonyms []string `json:"synonyms"`
+}
+
+//go:embed palette.json
+var paletteJSON []byte
+
+// palette maps every known color name and synonym to its canonical color.
+var palette = loadPalette()
+
+func loadPalette() map[string]Color {
+	var entries []paletteEntry
+	if err := json.Unmarshal(paletteJSON, &entries); err != nil {
+		panic(err)
+	}
+	colors := make(map[string]Color)
+	for _, entry := range entries {
+		colors[entry.Name] = entry.Color
+		for _, synonym := range entry.Synonyms {
+			colors[synonym] = entry.Color
+		}
+	}
+	return colors
+}
+
+// LookupColor returns the canonical color for a name or synonym, ignoring case and extra whitespace.
+// Unknown names are returned as-is with Unknown set.
+func LookupColor(name string) Color {
+	normalized := strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	if color, ok := palette[normalized]; ok {
+		return color
+	}
+	return Color{
+		Name:    normalized,
+		Unknown: true,
+	}
+}
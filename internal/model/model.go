@@ -1,21 +1,83 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"time"
+)
 
 type Events struct {
 	Events []Event `json:"events"`
 }
 
 type Event struct {
-	DateString     string    `json:"date_string"`
-	StartTimeStamp time.Time `json:"start_timestamp"`
-	Color          string    `json:"color"`
-	Description    string    `json:"description"`
-	RawEventString string    `json:"raw_event_string"`
+	DateString     string      `json:"date_string"`
+	StartTimeStamp time.Time   `json:"start_timestamp"`
+	EndTimeStamp   time.Time   `json:"end_timestamp"`
+	Nights         []time.Time `json:"nights,omitempty"`
+	Color          string      `json:"color"`
+	Colors         []Color     `json:"colors,omitempty"`
+	Description    string      `json:"description"`
+	Purpose        Purpose     `json:"purpose"`
+	RawEventString string      `json:"raw_event_string"`
+	// Seq numbers the listings that start on the same night, in schedule order, from 0.
+	Seq int `json:"seq,omitempty"`
+	// ID is the key the event was first stored under. It is only set once sf.gov moves the event's first night.
+	ID string `json:"id,omitempty"`
+}
+
+// Key identifies an event across scrapes of the same schedule, so that a recolored, re-described or
+// rescheduled listing is recognized as the same event.
+func (e Event) Key() string {
+	if e.ID != "" {
+		return e.ID
+	}
+	return e.ListingKey()
+}
+
+// ListingKey identifies a listing by the date of its first night, followed by its place among the listings
+// starting that night after the first, e.g. "2024-11-20#2".
+func (e Event) ListingKey() string {
+	key := e.StartTimeStamp.Format(time.DateOnly)
+	if e.Seq > 0 {
+		key += fmt.Sprintf("#%d", e.Seq+1)
+	}
+	return key
+}
+
+type PurposeKind string
+
+const (
+	PurposeRecognition   PurposeKind = "recognition"
+	PurposeCommemoration PurposeKind = "commemoration"
+	PurposeCelebration   PurposeKind = "celebration"
+	PurposeCampaign      PurposeKind = "campaign"
+)
+
+// PurposeKinds lists every kind of purpose.
+var PurposeKinds = []PurposeKind{PurposeRecognition, PurposeCommemoration, PurposeCelebration, PurposeCampaign}
+
+// ParsePurposeKind returns the kind of purpose named name, failing unless it is one of PurposeKinds.
+func ParsePurposeKind(name string) (PurposeKind, error) {
+	kind := PurposeKind(name)
+	if !slices.Contains(PurposeKinds, kind) {
+		return "", fmt.Errorf("invalid purpose %q", name)
+	}
+	return kind, nil
+}
+
+// Purpose is the structured reading of an event description, e.g. "in recognition of the San Francisco
+// Symphony’s annual Dia de los Muertos Celebration" is a recognition honoring the San Francisco Symphony for
+// the Dia de los Muertos Celebration.
+type Purpose struct {
+	Kind     PurposeKind `json:"kind,omitempty"`
+	Honoree  string      `json:"honoree,omitempty"`
+	Occasion string      `json:"occasion,omitempty"`
 }
 
 type ImageMetadata struct {
 	FileName    string      `json:"file_name"`
+	Colors      []string    `json:"colors"`
 	AltText     string      `json:"alt_text"`
 	Attribution Attribution `json:"attribution"`
 }
@@ -26,3 +88,85 @@ type Attribution struct {
 	SourceURL  string `json:"source_url"`
 	LicenseURL string `json:"license_url"`
 }
+
+type UnparsedLine struct {
+	RawEventString string `json:"raw_event_string"`
+	Stage          string `json:"stage"`
+	Offending      string `json:"offending"`
+	Suggestion     string `json:"suggestion"`
+	Error          string `json:"error"`
+}
+
+type ChangeKind string
+
+const (
+	ChangeAdded       ChangeKind = "added"
+	ChangeRemoved     ChangeKind = "removed"
+	ChangeRecolored   ChangeKind = "recolored"
+	ChangeRedescribed ChangeKind = "redescribed"
+	ChangeRescheduled ChangeKind = "rescheduled"
+)
+
+// Change is a difference between the stored events of a month and a later scrape of its schedule.
+// Before is unset for added events and After for removed ones.
+type Change struct {
+	Kind       ChangeKind `json:"kind"`
+	Key        string     `json:"key"`
+	Before     *Event     `json:"before,omitempty"`
+	After      *Event     `json:"after,omitempty"`
+	DetectedAt time.Time  `json:"detected_at"`
+}
+
+type RevisionReason string
+
+const (
+	RevisionScrape    RevisionReason = "scrape"
+	RevisionManual    RevisionReason = "manual"
+	RevisionMigration RevisionReason = "migration"
+)
+
+// Revision is one stored version of an event, kept so that earlier versions of a listing can be looked up
+// after sf.gov changes it. Event is unset when the event was taken off the schedule. SourceHash and FetchedAt
+// identify the scrape of the page the version came from, and are unset for manual overrides.
+type Revision struct {
+	Key        string         `json:"key"`
+	Event      *Event         `json:"event,omitempty"`
+	Reason     RevisionReason `json:"reason"`
+	SourceHash string         `json:"source_hash,omitempty"`
+	FetchedAt  time.Time      `json:"fetched_at"`
+	RecordedAt time.Time      `json:"recorded_at"`
+}
+
+// Post is a published post announcing the lighting of an event on one night.
+type Post struct {
+	EventKey string    `json:"event_key"`
+	Night    time.Time `json:"night"`
+	URI      string    `json:"uri"`
+	CID      string    `json:"cid"`
+	// BlobCID is the CID of the image uploaded with the post.
+	BlobCID  string    `json:"blob_cid,omitempty"`
+	PostedAt time.Time `json:"posted_at"`
+	// ReplyTo is the URI of the post a correction replies to.
+	ReplyTo string `json:"reply_to,omitempty"`
+	// Deleted is set once the post has been taken down to be replaced by a corrected one.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// ScrapeRun records one fetch of the lighting schedule page.
+type ScrapeRun struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	URL       string    `json:"url"`
+	Hash      string    `json:"hash"`
+	Month     time.Time `json:"month"`
+	Cached    bool      `json:"cached"`
+	Events    int       `json:"events"`
+	Rejected  int       `json:"rejected"`
+}
+
+// Source identifies the scrape a month's schedule was stored from. Months imported from saved pages have no
+// URL or hash.
+type Source struct {
+	ScrapedAt time.Time `json:"scraped_at"`
+	URL       string    `json:"source_url"`
+	Hash      string    `json:"source_hash"`
+}
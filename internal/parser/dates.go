@@ -0,0 +1,280 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateNotation recognizes one way of writing the nights of an event. parse returns ok false when the
+// expression is not written in the notation, so that the next notation can be tried.
+type dateNotation struct {
+	name  string
+	parse func(expr string, scheduleMonth time.Time) (nights []time.Time, ok bool, err error)
+}
+
+// dateNotations are tried in order until one recognizes a date expression. Support for a new way of writing
+// dates is added by appending to this list. Expressions joining several dates, e.g. "Sunday, November 3 and
+// Monday, November 4", are split first and each date is parsed with these notations.
+var dateNotations = []dateNotation{
+	{name: "long date", parse: parseLongDate},
+	{name: "numeric date", parse: parseNumericDate},
+	{name: "month and days", parse: parseMonthDays},
+}
+
+const (
+	weekdayPattern = `(?:mon|tues?|wed(?:nes)?|thu(?:rs?)?|fri|sat(?:ur)?|sun)(?:day)?\.?`
+	monthPattern   = `(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sept?(?:ember)?|` +
+		`oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)\.?`
+	dayListPattern = `(\d{1,2}(?:\s*(?:,|&|and|-|–|through|thru|to)\s*\d{1,2})*)`
+)
+
+var (
+	// numericDateRE matches "11/6", "11/6/2024" and ranges such as "11/1 - 11/6".
+	numericDateRE = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})(?:/(\d{2}|\d{4}))?` +
+		`(?:\s*(?:[-–]|through|thru|to)\s*(\d{1,2})/(\d{1,2})(?:/(\d{2}|\d{4}))?)?$`)
+	// monthDaysRE matches a month followed by one or more days, optionally led by weekdays and followed by a
+	// year, e.g. "Nov. 2", "November 28-29", "Thursday and Friday, November 28 and 29" or "Nov 1, 3 and 5".
+	monthDaysRE = regexp.MustCompile(`(?i)^(?:` + weekdayPattern + `(?:\s*(?:,|&|and)\s*` + weekdayPattern +
+		`)*\s*,?\s*)?` + monthPattern + `\s+` + dayListPattern + `(?:\s*,\s*(\d{4}))?$`)
+	// dayTokenRE splits a day list into days and the connectors between them.
+	dayTokenRE = regexp.MustCompile(`(?i)\d+|[-–]|through|thru|to`)
+	// compoundSplitRE matches the connectors between the dates of a compound expression.
+	compoundSplitRE = regexp.MustCompile(`(?i)(?:\s*,\s*(and|&)|\s+(and|&|through|thru|to|[-–])|\s*(,))\s+`)
+	// weekdayStartRE and monthStartRE match the start of a date, used to tell the dates of a compound
+	// expression apart from lists inside a single date.
+	weekdayStartRE = regexp.MustCompile(`(?i)^` + weekdayPattern + `(?:\W|$)`)
+	monthStartRE   = regexp.MustCompile(`(?i)^` + monthPattern + `(?:\W|$)`)
+	monthWordRE    = regexp.MustCompile(`(?i)\b` + monthPattern + `(?:\W|$)`)
+)
+
+// parseDateExpression returns every night covered by a date expression, in order.
+func parseDateExpression(expr string, scheduleMonth time.Time) ([]time.Time, error) {
+	expr = strings.TrimSpace(expr)
+	nights, ok, err := parseSingleExpression(expr, scheduleMonth)
+	if err != nil {
+		return nil, dateError(expr, err)
+	}
+	if ok {
+		return sortNights(nights), nil
+	}
+	return parseCompound(expr, scheduleMonth)
+}
+
+// parseSingleExpression tries each notation in turn.
+func parseSingleExpression(expr string, scheduleMonth time.Time) ([]time.Time, bool, error) {
+	for _, notation := range dateNotations {
+		nights, ok, err := notation.parse(expr, scheduleMonth)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid %s: %w", notation.name, err)
+		}
+		if ok {
+			return nights, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// parseCompound splits an expression into the dates it joins. Dates joined by "and" are listed, dates joined
+// by "through", "to" or a dash are a range covering every night in between.
+func parseCompound(expr string, scheduleMonth time.Time) ([]time.Time, error) {
+	type piece struct {
+		connector string
+		text      string
+	}
+	pieces := []piece{}
+	last := 0
+	connector := ""
+	for _, loc := range compoundSplitRE.FindAllStringSubmatchIndex(expr, -1) {
+		next, current := expr[loc[1]:], expr[last:loc[0]]
+		startsDate := weekdayStartRE.MatchString(next) || monthStartRE.MatchString(next)
+		if loc[6] >= 0 {
+			// a comma also follows the weekday and precedes the year of a single date, so it only joins two
+			// dates when a new weekday, or a second month, follows it
+			startsDate = weekdayStartRE.MatchString(next) ||
+				(monthStartRE.MatchString(next) && monthWordRE.MatchString(current))
+		}
+		if !startsDate {
+			// a list inside a single date, e.g. "Nov 1, 3 and 5"
+			continue
+		}
+		pieces = append(pieces, piece{connector: connector, text: current})
+		connector = "and"
+		if loc[4] >= 0 {
+			connector = strings.ToLower(expr[loc[4]:loc[5]])
+		}
+		last = loc[1]
+	}
+	pieces = append(pieces, piece{connector: connector, text: expr[last:]})
+	if len(pieces) == 1 {
+		return nil, dateError(expr, fmt.Errorf("unrecognized date notation"))
+	}
+
+	nights := []time.Time{}
+	for _, p := range pieces {
+		parsed, ok, err := parseSingleExpression(p.text, scheduleMonth)
+		if err != nil {
+			return nil, dateError(p.text, err)
+		}
+		if !ok {
+			return nil, dateError(p.text, fmt.Errorf("unrecognized date notation"))
+		}
+		switch p.connector {
+		case "", "and", "&":
+			nights = append(nights, parsed...)
+		default:
+			start := nights[len(nights)-1]
+			parsed = rollOverYear(start, parsed)
+			end := parsed[0]
+			if end.Before(start) {
+				return nil, dateError(expr, fmt.Errorf("invalid range: %s ends before it starts", expr))
+			}
+			nights = append(nights, expandRange(start, end)[1:]...)
+			nights = append(nights, parsed[1:]...)
+		}
+	}
+	return sortNights(nights), nil
+}
+
+// rollOverYear moves the end of a range that crosses into January into the following year. This covers ranges
+// placed in the wrong year when the year is only written on one side, e.g. "Tuesday, December 31, 2024 through
+// Wednesday, January 1".
+func rollOverYear(start time.Time, end []time.Time) []time.Time {
+	if !end[0].Before(start) || end[0].Month() >= start.Month() {
+		return end
+	}
+	rolled := make([]time.Time, len(end))
+	for i, night := range end {
+		rolled[i] = night.AddDate(1, 0, 0)
+	}
+	if rolled[0].Sub(start) > 31*24*time.Hour {
+		return end
+	}
+	return rolled
+}
+
+// parseLongDate parses dates as written on the current schedule, e.g. "Saturday, November 2, 2024".
+func parseLongDate(expr string, scheduleMonth time.Time) ([]time.Time, bool, error) {
+	date, err := parseSingleDate(expr, "Monday, January 2, 2006", "Monday, January 2", scheduleMonth)
+	if err != nil {
+		return nil, false, nil
+	}
+	return []time.Time{date}, true, nil
+}
+
+// parseNumericDate parses month/day dates, e.g. "11/6" or "11/1 - 11/6".
+func parseNumericDate(expr string, scheduleMonth time.Time) ([]time.Time, bool, error) {
+	m := numericDateRE.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, false, nil
+	}
+	start, err := numericDate(m[1], m[2], m[3], scheduleMonth)
+	if err != nil {
+		return nil, true, err
+	}
+	if m[4] == "" {
+		return []time.Time{start}, true, nil
+	}
+	end, err := numericDate(m[4], m[5], m[6], scheduleMonth)
+	if err != nil {
+		return nil, true, err
+	}
+	if end.Before(start) {
+		return nil, true, fmt.Errorf("%s ends before it starts", expr)
+	}
+	return expandRange(start, end), true, nil
+}
+
+func numericDate(month, day, year string, scheduleMonth time.Time) (time.Time, error) {
+	m, _ := strconv.Atoi(month)
+	if m < 1 || m > 12 {
+		return time.Time{}, fmt.Errorf("month %s out of range", month)
+	}
+	y := inferYear(time.Month(m), scheduleMonth)
+	if year != "" {
+		y, _ = strconv.Atoi(year)
+		if len(year) == 2 {
+			y += 2000
+		}
+	}
+	d, _ := strconv.Atoi(day)
+	return newDate(y, time.Month(m), d)
+}
+
+// parseMonthDays parses a month followed by a list or range of days, e.g. "November 28-29" or "Nov 1, 3 and 5".
+func parseMonthDays(expr string, scheduleMonth time.Time) ([]time.Time, bool, error) {
+	m := monthDaysRE.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, false, nil
+	}
+	month := monthByPrefix(m[1])
+	year := inferYear(month, scheduleMonth)
+	if m[3] != "" {
+		year, _ = strconv.Atoi(m[3])
+	}
+
+	nights := []time.Time{}
+	inRange := false
+	for _, token := range dayTokenRE.FindAllString(m[2], -1) {
+		day, err := strconv.Atoi(token)
+		if err != nil {
+			// a range connector, the next day closes the range
+			inRange = true
+			continue
+		}
+		date, err := newDate(year, month, day)
+		if err != nil {
+			return nil, true, err
+		}
+		if inRange {
+			start := nights[len(nights)-1]
+			if date.Before(start) {
+				return nil, true, fmt.Errorf("%s ends before it starts", expr)
+			}
+			nights = append(nights, expandRange(start, date)[1:]...)
+			inRange = false
+			continue
+		}
+		nights = append(nights, date)
+	}
+	return nights, true, nil
+}
+
+var monthPrefixes = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+func monthByPrefix(name string) time.Month {
+	return monthPrefixes[strings.ToLower(name[:3])]
+}
+
+// newDate returns midnight of the given day in San Francisco, rejecting days the month does not have.
+func newDate(year int, month time.Month, day int) (time.Time, error) {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load location: %w", err)
+	}
+	date := time.Date(year, month, day, 0, 0, 0, 0, location)
+	if date.Month() != month || date.Day() != day {
+		return time.Time{}, fmt.Errorf("day %d out of range for %s %d", day, month, year)
+	}
+	return date, nil
+}
+
+// sortNights orders nights and drops duplicates listed more than once.
+func sortNights(nights []time.Time) []time.Time {
+	sort.Slice(nights, func(i, j int) bool { return nights[i].Before(nights[j]) })
+	unique := nights[:0]
+	for i, night := range nights {
+		if i > 0 && night.Equal(unique[len(unique)-1]) {
+			continue
+		}
+		unique = append(unique, night)
+	}
+	return unique
+}
@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseDateExpression(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	nov := func(days ...int) []time.Time {
+		dates := []time.Time{}
+		for _, day := range days {
+			dates = append(dates, time.Date(2024, 11, day, 0, 0, 0, 0, loc))
+		}
+		return dates
+	}
+	type args struct {
+		expr          string
+		scheduleMonth time.Time
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      []time.Time
+		wantErr   bool
+		offending string
+	}{
+		{
+			name: "parses a long date",
+			args: args{expr: "Saturday, November 2, 2024"},
+			want: nov(2),
+		},
+		{
+			name: "parses a numeric date",
+			args: args{expr: "11/9"},
+			want: nov(9),
+		},
+		{
+			name: "parses a numeric date with year",
+			args: args{expr: "11/9/24"},
+			want: nov(9),
+		},
+		{
+			name: "expands a numeric range",
+			args: args{expr: "11/1 - 11/6"},
+			want: nov(1, 2, 3, 4, 5, 6),
+		},
+		{
+			name: "parses an abbreviated month",
+			args: args{expr: "Nov. 2"},
+			want: nov(2),
+		},
+		{
+			name: "parses an abbreviated month without a period",
+			args: args{expr: "Nov 2, 2024"},
+			want: nov(2),
+		},
+		{
+			name: "expands a range of days in one month",
+			args: args{expr: "November 28-29"},
+			want: nov(28, 29),
+		},
+		{
+			name: "expands a range of days written with an en dash",
+			args: args{expr: "Thursday, November 28–30, 2024"},
+			want: nov(28, 29, 30),
+		},
+		{
+			name: "parses listed weekdays and days",
+			args: args{expr: "Thursday and Friday, November 28 and 29"},
+			want: nov(28, 29),
+		},
+		{
+			name: "parses three or more listed days",
+			args: args{expr: "Nov 1, 3 and 5"},
+			want: nov(1, 3, 5),
+		},
+		{
+			name: "parses listed long dates",
+			args: args{expr: "Sunday, November 3 and Monday, November 4, 2024"},
+			want: nov(3, 4),
+		},
+		{
+			name: "parses three or more listed long dates",
+			args: args{expr: "Friday, November 1, Sunday, November 3 and Tuesday, November 5"},
+			want: nov(1, 3, 5),
+		},
+		{
+			name: "parses listed long dates with an oxford comma",
+			args: args{expr: "Friday, November 1, Sunday, November 3, and Tuesday, November 5, 2024"},
+			want: nov(1, 3, 5),
+		},
+		{
+			name: "parses listed abbreviated dates",
+			args: args{expr: "Nov 1, Nov 3 and Nov 5"},
+			want: nov(1, 3, 5),
+		},
+		{
+			name: "expands a range of long dates",
+			args: args{expr: "Monday, November 25 through Friday, November 29, 2024"},
+			want: nov(25, 26, 27, 28, 29),
+		},
+		{
+			name: "expands a range of abbreviated dates",
+			args: args{expr: "Nov 25 to Nov 27"},
+			want: nov(25, 26, 27),
+		},
+		{
+			name: "expands a range crossing into the next month",
+			args: args{expr: "Saturday, November 30 through Sunday, December 1"},
+			want: []time.Time{
+				time.Date(2024, 11, 30, 0, 0, 0, 0, loc),
+				time.Date(2024, 12, 1, 0, 0, 0, 0, loc),
+			},
+		},
+		{
+			name: "expands a dashed range crossing into the next month",
+			args: args{expr: "November 30 - December 2"},
+			want: []time.Time{
+				time.Date(2024, 11, 30, 0, 0, 0, 0, loc),
+				time.Date(2024, 12, 1, 0, 0, 0, 0, loc),
+				time.Date(2024, 12, 2, 0, 0, 0, 0, loc),
+			},
+		},
+		{
+			name: "expands a range crossing into the next year on a December schedule",
+			args: args{
+				expr:          "Tuesday, December 31 through Wednesday, January 1",
+				scheduleMonth: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: []time.Time{
+				time.Date(2024, 12, 31, 0, 0, 0, 0, loc),
+				time.Date(2025, 1, 1, 0, 0, 0, 0, loc),
+			},
+		},
+		{
+			name: "expands a range crossing into the next year on a January schedule",
+			args: args{
+				expr:          "Tuesday, December 31 through Wednesday, January 1",
+				scheduleMonth: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: []time.Time{
+				time.Date(2024, 12, 31, 0, 0, 0, 0, loc),
+				time.Date(2025, 1, 1, 0, 0, 0, 0, loc),
+			},
+		},
+		{
+			name: "rolls the end of a range written with the old year into the next year",
+			args: args{
+				expr:          "Tuesday, December 31, 2024 through Wednesday, January 1, 2024",
+				scheduleMonth: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: []time.Time{
+				time.Date(2024, 12, 31, 0, 0, 0, 0, loc),
+				time.Date(2025, 1, 1, 0, 0, 0, 0, loc),
+			},
+		},
+		{
+			name: "lists each night once",
+			args: args{expr: "Nov 1, 1 and 2"},
+			want: nov(1, 2),
+		},
+		{
+			name:      "day missing from the month fails",
+			args:      args{expr: "Nov 1 and 31"},
+			wantErr:   true,
+			offending: "Nov 1 and 31",
+		},
+		{
+			name:      "invalid date inside a compound expression reports that date",
+			args:      args{expr: "Friday, November 1 and Saturday, November 31"},
+			wantErr:   true,
+			offending: "Saturday, November 31",
+		},
+		{
+			name:      "unknown notation fails",
+			args:      args{expr: "the first weekend of November"},
+			wantErr:   true,
+			offending: "the first weekend of November",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduleMonth := tt.args.scheduleMonth
+			if scheduleMonth.IsZero() {
+				scheduleMonth = time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+			}
+			got, err := parseDateExpression(tt.args.expr, scheduleMonth)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDateExpression() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				var parseErr *ParseError
+				require.ErrorAs(t, err, &parseErr)
+				require.Equal(t, StageDate, parseErr.Stage)
+				require.Equal(t, tt.offending, parseErr.Offending)
+				return
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
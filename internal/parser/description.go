@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"city-hall-lights/internal/model"
+)
+
+// purposePrefixes are the phrases descriptions open with, mapped to the purpose they state.
+var purposePrefixes = []struct {
+	prefix string
+	kind   model.PurposeKind
+}{
+	{prefix: "in recognition of ", kind: model.PurposeRecognition},
+	{prefix: "in honor of ", kind: model.PurposeRecognition},
+	{prefix: "to recognize ", kind: model.PurposeRecognition},
+	{prefix: "to commemorate ", kind: model.PurposeCommemoration},
+	{prefix: "in commemoration of ", kind: model.PurposeCommemoration},
+	{prefix: "in memory of ", kind: model.PurposeCommemoration},
+	{prefix: "in celebration of ", kind: model.PurposeCelebration},
+	{prefix: "to celebrate ", kind: model.PurposeCelebration},
+	{prefix: "in support of ", kind: model.PurposeCampaign},
+}
+
+var (
+	// possessiveRE matches "<honoree>’s [annual] <occasion>", e.g. "San Francisco Symphony’s annual Dia de los
+	// Muertos Celebration" or "Alzheimer Foundations’ annual ...".
+	possessiveRE = regexp.MustCompile(`^(.+?)['’]s?\s+(?:annual\s+)?(.+)$`)
+	// namedCampaignRE matches an organization followed by a quoted name, e.g. `SFDPH "Living Proof" campaign`.
+	namedCampaignRE = regexp.MustCompile(`^([A-Z][\w&.]*(?:\s+[A-Z][\w&.]*)*)\s+(".+)$`)
+	// acronymRE matches honorees written as an acronym, e.g. "SFDPH".
+	acronymRE = regexp.MustCompile(`^[A-Z0-9&.]{2,}$`)
+	// observanceRE matches what follows the possessive in the name of an observance, e.g. the "Day" of
+	// "International Women’s Day" or the "History Month" of "Women’s History Month".
+	observanceRE = regexp.MustCompile(`^(?:History\s+)?(?:Day|Week|Month|Year)\b`)
+)
+
+// extractPurpose splits a raw description into its purpose, honoree and occasion. Parts that cannot be
+// recognized are left empty, with the occasion falling back to the whole description.
+func extractPurpose(description string) model.Purpose {
+	desc := strings.TrimSpace(description)
+	desc = strings.NewReplacer(`“`, `"`, `”`, `"`).Replace(desc)
+
+	purpose := model.Purpose{}
+	for _, p := range purposePrefixes {
+		if strings.HasPrefix(strings.ToLower(desc), p.prefix) {
+			purpose.Kind = p.kind
+			desc = desc[len(p.prefix):]
+			break
+		}
+	}
+	// further clauses add context, e.g. "...; this is part of the annual United Nations Campaign"
+	desc, _, _ = strings.Cut(desc, ";")
+	desc = strings.TrimPrefix(strings.TrimSpace(desc), "the ")
+
+	purpose.Occasion = desc
+	if m := possessiveRE.FindStringSubmatch(desc); m != nil && isHonoree(m[1], m[2]) {
+		purpose.Honoree, purpose.Occasion = m[1], m[2]
+	} else if m := namedCampaignRE.FindStringSubmatch(desc); m != nil {
+		purpose.Honoree, purpose.Occasion = m[1], m[2]
+	}
+
+	if purpose.Kind == "" {
+		purpose.Kind = kindFromOccasion(purpose.Occasion)
+	}
+	return purpose
+}
+
+// isHonoree reports whether a possessive names an organization or person rather than being part of a holiday
+// name such as "Veteran’s Day" or "International Women’s Day", given the rest of the description. Single words
+// only count when written as an acronym.
+func isHonoree(name, rest string) bool {
+	if observanceRE.MatchString(rest) {
+		return false
+	}
+	return len(strings.Fields(name)) > 1 || acronymRE.MatchString(name)
+}
+
+// kindFromOccasion infers the purpose of descriptions that do not open with one.
+func kindFromOccasion(occasion string) model.PurposeKind {
+	lowered := strings.ToLower(occasion)
+	switch {
+	case strings.HasSuffix(lowered, "campaign"):
+		return model.PurposeCampaign
+	case strings.Contains(lowered, "celebration"):
+		return model.PurposeCelebration
+	default:
+		return model.PurposeRecognition
+	}
+}
@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"testing"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_extractPurpose(t *testing.T) {
+	type args struct {
+		description string
+	}
+	tests := []struct {
+		name string
+		args args
+		want model.Purpose
+	}{
+		{
+			name: "extracts honoree and occasion from a possessive",
+			args: args{
+				description: "in recognition of the San Francisco Symphony’s annual Dia de los Muertos Celebration",
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeRecognition,
+				Honoree:  "San Francisco Symphony",
+				Occasion: "Dia de los Muertos Celebration",
+			},
+		},
+		{
+			name: "extracts honoree from a plural possessive and normalizes quotes",
+			args: args{
+				description: "in recognition of the Alzheimer Foundations’ annual “Light the World Teal” Campaign",
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeRecognition,
+				Honoree:  "Alzheimer Foundations",
+				Occasion: `"Light the World Teal" Campaign`,
+			},
+		},
+		{
+			name: "extracts a commemoration",
+			args: args{
+				description: "to commemorate the Legion of Honor’s 100th Anniversary and the U.S./France Relationship",
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeCommemoration,
+				Honoree:  "Legion of Honor",
+				Occasion: "100th Anniversary and the U.S./France Relationship",
+			},
+		},
+		{
+			name: "keeps a possessive holiday name as the occasion",
+			args: args{
+				description: "in recognition of the Veteran’s Day Holiday",
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeRecognition,
+				Occasion: "Veteran’s Day Holiday",
+			},
+		},
+		{
+			name: "keeps a possessive observance of several words as the occasion",
+			args: args{
+				description: "in recognition of International Women’s Day",
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeRecognition,
+				Occasion: "International Women’s Day",
+			},
+		},
+		{
+			name: "keeps an observance with a straight apostrophe as the occasion",
+			args: args{
+				description: "in celebration of World Children's Day",
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeCelebration,
+				Occasion: "World Children's Day",
+			},
+		},
+		{
+			name: "keeps a history month as the occasion",
+			args: args{
+				description: "in recognition of Asian American and Pacific Islander Women’s History Month",
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeRecognition,
+				Occasion: "Asian American and Pacific Islander Women’s History Month",
+			},
+		},
+		{
+			name: "extracts an organization's campaign without a purpose phrase",
+			args: args{
+				description: `SFDPH "Living Proof" campaign`,
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeCampaign,
+				Honoree:  "SFDPH",
+				Occasion: `"Living Proof" campaign`,
+			},
+		},
+		{
+			name: "extracts a celebration",
+			args: args{
+				description: "to celebrate Lunar New Year",
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeCelebration,
+				Occasion: "Lunar New Year",
+			},
+		},
+		{
+			name: "drops clauses after a semicolon from the occasion",
+			args: args{
+				description: "in recognition of the International Day of Elimination of Violence Against Women; this is part of the annual United Nations Campaign: 16 Days of Activism Against Gender Based Violence",
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeRecognition,
+				Occasion: "International Day of Elimination of Violence Against Women",
+			},
+		},
+		{
+			name: "infers a celebration without a purpose phrase",
+			args: args{
+				description: "Bhanga and Beats Night Market Diwali Celebration",
+			},
+			want: model.Purpose{
+				Kind:     model.PurposeCelebration,
+				Occasion: "Bhanga and Beats Night Market Diwali Celebration",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, extractPurpose(tt.args.description))
+		})
+	}
+}
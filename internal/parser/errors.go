@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"fmt"
+
+	"city-hall-lights/internal/model"
+)
+
+// Stage names the step of parsing a line that failed.
+type Stage string
+
+const (
+	StageSplit Stage = "split"
+	StageDate  Stage = "date"
+	StageColor Stage = "color"
+)
+
+// ParseError describes why a raw event string could not be parsed, and how the line could be fixed.
+type ParseError struct {
+	Stage      Stage
+	Line       string
+	Offending  string
+	Suggestion string
+	Err        error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse %s of %q: %q: %v", e.Stage, e.Line, e.Offending, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// UnparsedLine converts the error into the record kept in the store for operator review.
+func (e *ParseError) UnparsedLine() model.UnparsedLine {
+	return model.UnparsedLine{
+		RawEventString: e.Line,
+		Stage:          string(e.Stage),
+		Offending:      e.Offending,
+		Suggestion:     e.Suggestion,
+		Error:          e.Err.Error(),
+	}
+}
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultSelector locates the lighting schedule section of the sf.gov City Hall page by its position.
+// It breaks whenever sections are added or reordered, so SummaryLocator is tried after it.
+const DefaultSelector = `#block-sfgovpl-content > article > div.sfgov-section-container >
+						 div.group--left > div.sfgov-section.sfgov-section-getting-here > div >
+						 div.field.field--type-entity-reference-revisions.__getting-here-items.field__items >
+						 div:nth-child(5) > details > div > div`
+
+// DefaultSummary is the heading of the collapsible section holding the lighting schedule.
+const DefaultSummary = "Lighting schedule"
+
+// Locator is a strategy for finding the lighting schedule section of a page.
+type Locator struct {
+	Name string
+	Find func(doc *goquery.Document) *goquery.Selection
+}
+
+// SelectorLocator finds the schedule section with a CSS selector.
+func SelectorLocator(selector string) Locator {
+	return Locator{
+		Name: "selector",
+		Find: func(doc *goquery.Document) *goquery.Selection {
+			return doc.Find(selector).First()
+		},
+	}
+}
+
+// SummaryLocator finds the schedule section by the <summary> heading of the <details> element holding it,
+// wherever that element is on the page.
+func SummaryLocator(summary string) Locator {
+	return Locator{
+		Name: "summary",
+		Find: func(doc *goquery.Document) *goquery.Selection {
+			return doc.Find("details").FilterFunction(func(_ int, details *goquery.Selection) bool {
+				return strings.EqualFold(cleanText(details.ChildrenFiltered("summary").Text()), summary)
+			}).First()
+		},
+	}
+}
+
+// DefaultLocators returns the strategies used to find the schedule on the current sf.gov page.
+func DefaultLocators() []Locator {
+	return []Locator{
+		SelectorLocator(DefaultSelector),
+		SummaryLocator(DefaultSummary),
+	}
+}
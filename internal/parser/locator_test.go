@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScheduleWith_locators(t *testing.T) {
+	fixture, err := os.ReadFile("test-fixtures/san-francisco-city-hall.html")
+	require.NoError(t, err)
+	page := string(fixture)
+
+	// removing an earlier section moves the schedule out of the position DefaultSelector expects
+	toursStart := strings.Index(page, `<div class="field__item">  <h3 class="sr-only">For City Hall tours</h3>`)
+	toursEnd := strings.Index(page, `<div class="field__item">  <h3 class="sr-only">Lighting schedule</h3>`)
+	require.True(t, toursStart > 0 && toursEnd > toursStart)
+	reordered := page[:toursStart] + page[toursEnd:]
+
+	tests := []struct {
+		name        string
+		page        string
+		locators    []Locator
+		wantLocator string
+		wantErr     []string
+	}{
+		{
+			name:        "finds the schedule by its position",
+			page:        page,
+			locators:    DefaultLocators(),
+			wantLocator: "selector",
+		},
+		{
+			name:        "falls back to the summary heading when the sections are reordered",
+			page:        reordered,
+			locators:    DefaultLocators(),
+			wantLocator: "summary",
+		},
+		{
+			name:        "finds the schedule with a configured selector",
+			page:        reordered,
+			locators:    []Locator{SelectorLocator("div.__getting-here-items > div:nth-child(4) > details > div > div")},
+			wantLocator: "selector",
+		},
+		{
+			name:     "fails when no strategy finds events",
+			page:     reordered,
+			locators: []Locator{SelectorLocator(DefaultSelector), SummaryLocator("Light show")},
+			wantErr: []string{
+				"lighting schedule not found on page",
+				"selector: failed to parse schedule heading",
+				"summary: no section found",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScheduleWith(strings.NewReader(tt.page), tt.locators)
+			if tt.wantErr != nil {
+				for _, want := range tt.wantErr {
+					require.ErrorContains(t, err, want)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantLocator, got.Locator)
+			require.Len(t, got.Events, 16)
+		})
+	}
+}
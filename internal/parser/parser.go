@@ -10,93 +10,122 @@ import (
 	"city-hall-lights/internal/model"
 )
 
-// colorSplitRE is used to split the color from the raw event string. Characters used are unfortunately not uniform,.
-var colorSplitRE = regexp.MustCompile("[-–]")
-
-// ParseEvent attempts to parse a raw event string into a structured event.
-// Since the data is not consistent, this method has a fair amount of extra complexity.
-func ParseEvent(rawEventString string) model.Event {
-	rawEventString = strings.Replace(rawEventString, ` `, ``, -1)
-	parts := colorSplitRE.Split(rawEventString, -1)
+// Result holds the outcome of parsing every line of a schedule.
+type Result struct {
+	Events   []model.Event
+	Rejected []model.UnparsedLine
+}
 
-	if len(parts) == 3 {
-		date := strings.TrimRight(parts[0], " ")
-		startTimeStamp, err := convertToTimestamps(date)
+// ParseEvents parses each raw event string, collecting the lines that fail for review instead of dropping them.
+func ParseEvents(rawEventStrings []string, scheduleMonth time.Time) Result {
+	result := Result{
+		Events:   []model.Event{},
+		Rejected: []model.UnparsedLine{},
+	}
+	// listings starting on the same night are numbered so that each keeps its own key
+	starts := make(map[string]int)
+	for _, rawEventString := range rawEventStrings {
+		event, err := ParseEvent(rawEventString, scheduleMonth)
 		if err != nil {
-			fmt.Println(err)
-			return model.Event{
-				RawEventString: rawEventString,
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				parseErr = &ParseError{Line: rawEventString, Offending: rawEventString, Err: err}
 			}
+			result.Rejected = append(result.Rejected, parseErr.UnparsedLine())
+			continue
 		}
-		color := strings.TrimSpace(parts[1])
-		description := transformDescription(parts[2], color)
-		return model.Event{
-			DateString:     date,
-			StartTimeStamp: startTimeStamp[0],
-			Color:          color,
-			Description:    description,
-			RawEventString: rawEventString,
-		}
-	}
-
-	return model.Event{
-		RawEventString: rawEventString,
+		event.Seq = starts[event.Key()]
+		starts[event.Key()]++
+		result.Events = append(result.Events, event)
 	}
+	return result
 }
 
-// convertToTimestamps attempts to convert a date string into a timestamp.
-// The language used to describe the date is not consistent.
-// The returned timestamp is needed for use in automated posting on a schedule.
-func convertToTimestamps(dateString string) ([]time.Time, error) {
-	layout := "Monday, January 2, 2006"      // Standard format with year
-	layoutWithoutYear := "Monday, January 2" // Format without year
-	year := 2024                             // Set the target year here, if known
-
-	// Trim and check if input contains "and" or "through"
-	dateString = strings.TrimSpace(dateString)
-	if strings.Contains(dateString, " and ") {
-		// Split and parse each date individually
-		parts := strings.Split(dateString, " and ")
-		dates := []time.Time{}
-
-		for _, part := range parts {
-			date, err := parseSingleDate(part, layout, layoutWithoutYear, year)
-			if err != nil {
-				return nil, err
-			}
-			dates = append(dates, date)
-		}
-		return dates, nil
+const (
+	splitSuggestion = `separate the fields as "<date> – <color> – <description>"`
+	dateSuggestion  = `write the date as "Saturday, November 2, 2024" or "Saturday, November 2"`
+	colorSuggestion = `name at least one color, e.g. "red/white/blue"`
+)
+
+// ParseEvent attempts to parse a raw event string into a structured event.
+// Since the data is not consistent, this method has a fair amount of extra complexity.
+// scheduleMonth is the month the schedule was published for, taken from the page header. It supplies the year
+// for dates listed without one. Failures are returned as a *ParseError naming the stage that failed.
+func ParseEvent(rawEventString string, scheduleMonth time.Time) (model.Event, error) {
+	rawEventString = strings.Replace(rawEventString, ` `, ``, -1)
+	fields, err := tokenize(rawEventString)
+	if err != nil {
+		return model.Event{}, err
 	}
 
-	if strings.Contains(dateString, " through ") {
-		// Split and parse start and end dates for a range
-		parts := strings.Split(dateString, " through ")
-		if len(parts) != 2 {
-			return nil, errors.New("invalid range format")
+	date := fields.date
+	nights, err := convertToTimestamps(date, scheduleMonth)
+	if err != nil {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.Line = rawEventString
+			return model.Event{}, parseErr
 		}
-
-		startDate, err := parseSingleDate(parts[0], layout, layoutWithoutYear, year)
-		if err != nil {
-			return nil, err
+		return model.Event{}, &ParseError{
+			Stage:      StageDate,
+			Line:       rawEventString,
+			Offending:  date,
+			Suggestion: dateSuggestion,
+			Err:        err,
 		}
-		endDate, err := parseSingleDate(parts[1], layout, layoutWithoutYear, year)
-		if err != nil {
-			return nil, err
+	}
+	color := fields.color
+	if _, err = transformColors(color); err != nil {
+		return model.Event{}, &ParseError{
+			Stage:      StageColor,
+			Line:       rawEventString,
+			Offending:  color,
+			Suggestion: colorSuggestion,
+			Err:        err,
 		}
+	}
+	description := transformDescription(fields.description, color)
+	return model.Event{
+		DateString:     date,
+		StartTimeStamp: nights[0],
+		EndTimeStamp:   nights[len(nights)-1],
+		Nights:         nights,
+		Color:          color,
+		Colors:         parseColors(color),
+		Description:    description,
+		Purpose:        extractPurpose(fields.description),
+		RawEventString: rawEventString,
+	}, nil
+}
 
-		return []time.Time{startDate, endDate}, nil
+// convertToTimestamps attempts to convert a date string into one timestamp per lit night.
+// The language used to describe the date is not consistent, see dateNotations for the supported notations.
+// Listed dates return each date, ranges are expanded day by day.
+// The returned timestamps are needed for use in automated posting on a schedule.
+func convertToTimestamps(dateString string, scheduleMonth time.Time) ([]time.Time, error) {
+	return parseDateExpression(dateString, scheduleMonth)
+}
+
+// dateError reports the part of a date string that could not be parsed.
+func dateError(part string, err error) *ParseError {
+	return &ParseError{
+		Stage:      StageDate,
+		Offending:  part,
+		Suggestion: dateSuggestion,
+		Err:        err,
 	}
+}
 
-	// If it's a single date
-	date, err := parseSingleDate(dateString, layout, layoutWithoutYear, year)
-	if err != nil {
-		return nil, err
+// expandRange returns every date from start to end, inclusive.
+func expandRange(start, end time.Time) []time.Time {
+	dates := []time.Time{}
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
 	}
-	return []time.Time{date}, nil
+	return dates
 }
 
-func parseSingleDate(dateStr, layout, layoutWithoutYear string, year int) (time.Time, error) {
+func parseSingleDate(dateStr, layout, layoutWithoutYear string, scheduleMonth time.Time) (time.Time, error) {
 	location, err := time.LoadLocation("America/Los_Angeles")
 	if err != nil {
 		return time.Time{}, fmt.Errorf("failed to load location: %w", err)
@@ -108,11 +137,26 @@ func parseSingleDate(dateStr, layout, layoutWithoutYear string, year int) (time.
 		if err != nil {
 			return time.Time{}, err
 		}
-		date = date.AddDate(year, 0, 0) // Add the target year
+		date = date.AddDate(inferYear(date.Month(), scheduleMonth), 0, 0) // Add the target year
 	}
 	return date, nil
 }
 
+// inferYear returns the year of a date listed without one on a schedule published for scheduleMonth.
+// Schedules only list dates close to the month they were published for, so a month more than half a year
+// away belongs to the neighbouring year, e.g. "Wednesday, January 1" on a December 2024 schedule is in 2025.
+func inferYear(month time.Month, scheduleMonth time.Time) int {
+	year := scheduleMonth.Year()
+	switch diff := int(month) - int(scheduleMonth.Month()); {
+	case diff < -6:
+		return year + 1
+	case diff > 6:
+		return year - 1
+	default:
+		return year
+	}
+}
+
 const (
 	recognitionPattern = "in recognition of "
 	commemoratePattern = "to commemorate "
@@ -131,6 +175,22 @@ func transformDescription(rawDescription string, color string) string {
 	return strings.TrimSpace(descTemplate)
 }
 
+// colorSeparatorRE matches the separators used between colors, e.g. "red/white/blue" or "pink and yellow".
+var colorSeparatorRE = regexp.MustCompile(`\s*(?:/|,|&|\band\b)\s*`)
+
+// parseColors maps each listed color to its canonical palette color. Colors missing from the palette are
+// returned with Unknown set, so that they can be flagged and added.
+func parseColors(colors string) []model.Color {
+	parsed := []model.Color{}
+	for _, name := range colorSeparatorRE.Split(colors, -1) {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		parsed = append(parsed, model.LookupColor(name))
+	}
+	return parsed
+}
+
 // transformColors converts input strings into a formatted list. Leading and trailing spaces are trimmed,
 // and input is lowercased. Oxford comma style is used for three or more colors.
 // Known formats:
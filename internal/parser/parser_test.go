@@ -10,30 +10,219 @@ import (
 )
 
 func TestParseEvent(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
 	type args struct {
 		rawEventString string
+		scheduleMonth  time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want model.Event
+		name      string
+		args      args
+		want      model.Event
+		wantErr   bool
+		wantStage Stage
+		offending string
 	}{
-		// TODO: Add test cases.
+		{
+			name: "parses single night event",
+			args: args{
+				scheduleMonth:  time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				rawEventString: "Monday, November 18, 2024 – purple – in recognition of World Prematurity Day",
+			},
+			want: model.Event{
+				DateString:     "Monday, November 18, 2024",
+				StartTimeStamp: time.Date(2024, 11, 18, 0, 0, 0, 0, loc),
+				EndTimeStamp:   time.Date(2024, 11, 18, 0, 0, 0, 0, loc),
+				Nights:         []time.Time{time.Date(2024, 11, 18, 0, 0, 0, 0, loc)},
+				Color:          "purple",
+				Colors:         []model.Color{{Name: "purple", Hex: "#6A0DAD", Emoji: "💜"}},
+				Description:    "Tonight City Hall will be purple in recognition of World Prematurity Day",
+				Purpose: model.Purpose{
+					Kind:     model.PurposeRecognition,
+					Occasion: "World Prematurity Day",
+				},
+				RawEventString: "Monday, November 18, 2024 – purple – in recognition of World Prematurity Day",
+			},
+		},
+		{
+			name: "parses every night of an 'and' listing",
+			args: args{
+				scheduleMonth:  time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				rawEventString: "Sunday, November 3 and Monday, November 4, 2024 – red/white/blue – in recognition of Get Out the Vote",
+			},
+			want: model.Event{
+				DateString:     "Sunday, November 3 and Monday, November 4, 2024",
+				StartTimeStamp: time.Date(2024, 11, 3, 0, 0, 0, 0, loc),
+				EndTimeStamp:   time.Date(2024, 11, 4, 0, 0, 0, 0, loc),
+				Nights: []time.Time{
+					time.Date(2024, 11, 3, 0, 0, 0, 0, loc),
+					time.Date(2024, 11, 4, 0, 0, 0, 0, loc),
+				},
+				Color: "red/white/blue",
+				Colors: []model.Color{
+					{Name: "red", Hex: "#D7263D", Emoji: "❤️"},
+					{Name: "white", Hex: "#FFFFFF", Emoji: "🤍"},
+					{Name: "blue", Hex: "#0057B8", Emoji: "💙"},
+				},
+				Description: "Tonight City Hall will be red, white, and blue in recognition of Get Out the Vote",
+				Purpose: model.Purpose{
+					Kind:     model.PurposeRecognition,
+					Occasion: "Get Out the Vote",
+				},
+				RawEventString: "Sunday, November 3 and Monday, November 4, 2024 – red/white/blue – in recognition of Get Out the Vote",
+			},
+		},
+		{
+			name: "expands a 'through' listing into every night",
+			args: args{
+				scheduleMonth:  time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				rawEventString: "Thursday, November 28 through Saturday, November 30, 2024 – shades of amber – in recognition of the Thanksgiving Holiday",
+			},
+			want: model.Event{
+				DateString:     "Thursday, November 28 through Saturday, November 30, 2024",
+				StartTimeStamp: time.Date(2024, 11, 28, 0, 0, 0, 0, loc),
+				EndTimeStamp:   time.Date(2024, 11, 30, 0, 0, 0, 0, loc),
+				Nights: []time.Time{
+					time.Date(2024, 11, 28, 0, 0, 0, 0, loc),
+					time.Date(2024, 11, 29, 0, 0, 0, 0, loc),
+					time.Date(2024, 11, 30, 0, 0, 0, 0, loc),
+				},
+				Color:       "shades of amber",
+				Colors:      []model.Color{{Name: "amber", Hex: "#FFBF00", Emoji: "🧡"}},
+				Description: "Tonight City Hall will be shades of amber in recognition of the Thanksgiving Holiday",
+				Purpose: model.Purpose{
+					Kind:     model.PurposeRecognition,
+					Occasion: "Thanksgiving Holiday",
+				},
+				RawEventString: "Thursday, November 28 through Saturday, November 30, 2024 – shades of amber – in recognition of the Thanksgiving Holiday",
+			},
+		},
+		{
+			name: "parses a numeric range laid out in columns",
+			args: args{
+				scheduleMonth:  time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				rawEventString: "11/1 - 11/3      Red/white/blue – Election!",
+			},
+			want: model.Event{
+				DateString:     "11/1 - 11/3",
+				StartTimeStamp: time.Date(2024, 11, 1, 0, 0, 0, 0, loc),
+				EndTimeStamp:   time.Date(2024, 11, 3, 0, 0, 0, 0, loc),
+				Nights: []time.Time{
+					time.Date(2024, 11, 1, 0, 0, 0, 0, loc),
+					time.Date(2024, 11, 2, 0, 0, 0, 0, loc),
+					time.Date(2024, 11, 3, 0, 0, 0, 0, loc),
+				},
+				Color: "Red/white/blue",
+				Colors: []model.Color{
+					{Name: "red", Hex: "#D7263D", Emoji: "❤️"},
+					{Name: "white", Hex: "#FFFFFF", Emoji: "🤍"},
+					{Name: "blue", Hex: "#0057B8", Emoji: "💙"},
+				},
+				Description: "Tonight City Hall will be red, white, and blue in recognition of Election!",
+				Purpose: model.Purpose{
+					Kind:     model.PurposeRecognition,
+					Occasion: "Election!",
+				},
+				RawEventString: "11/1 - 11/3      Red/white/blue – Election!",
+			},
+		},
+		{
+			name: "line without delimiters fails at the split stage",
+			args: args{
+				scheduleMonth:  time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				rawEventString: "Monday, November 18, 2024 purple in recognition of World Prematurity Day",
+			},
+			wantErr:   true,
+			wantStage: StageSplit,
+			offending: "purple in recognition of World Prematurity Day",
+		},
+		{
+			name: "day out of range fails at the date stage",
+			args: args{
+				scheduleMonth:  time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				rawEventString: "Sunday, November 3 and Monday, November 31, 2024 – red/white/blue – in recognition of Get Out the Vote",
+			},
+			wantErr:   true,
+			wantStage: StageDate,
+			offending: "Monday, November 31, 2024",
+		},
+		{
+			name: "empty color fails at the color stage",
+			args: args{
+				scheduleMonth:  time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				rawEventString: "Monday, November 18, 2024 – – in recognition of World Prematurity Day",
+			},
+			wantErr:   true,
+			wantStage: StageColor,
+			offending: "",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := ParseEvent(tt.args.rawEventString); !reflect.DeepEqual(got, tt.want) {
+			got, err := ParseEvent(tt.args.rawEventString, tt.args.scheduleMonth)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseEvent() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				var parseErr *ParseError
+				require.ErrorAs(t, err, &parseErr)
+				require.Equal(t, tt.wantStage, parseErr.Stage)
+				require.Equal(t, tt.offending, parseErr.Offending)
+				require.Equal(t, tt.args.rawEventString, parseErr.Line)
+				require.NotEmpty(t, parseErr.Suggestion)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ParseEvent() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestParseEvents(t *testing.T) {
+	scheduleMonth := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	got := ParseEvents([]string{
+		"Monday, November 18, 2024 – purple – in recognition of World Prematurity Day",
+		"Tuesday, November 19, 2024 red/white in recognition of the National Day of Monaco",
+		"Wednesday, November 20, 2024 – blue/pink/white – in recognition of Transgender Day of Remembrance",
+	}, scheduleMonth)
+
+	require.Len(t, got.Events, 2)
+	require.Equal(t, "Monday, November 18, 2024", got.Events[0].DateString)
+	require.Equal(t, "Wednesday, November 20, 2024", got.Events[1].DateString)
+	require.Equal(t, []model.UnparsedLine{
+		{
+			RawEventString: "Tuesday, November 19, 2024 red/white in recognition of the National Day of Monaco",
+			Stage:          string(StageSplit),
+			Offending:      "red/white in recognition of the National Day of Monaco",
+			Suggestion:     splitSuggestion,
+			Error:          "expected a delimiter after the date",
+		},
+	}, got.Rejected)
+}
+
+func TestParseEvents_sameNight(t *testing.T) {
+	got := ParseEvents([]string{
+		"Wednesday, November 20, 2024 – blue/pink/white – in recognition of Transgender Day of Remembrance",
+		"Wednesday, November 20, 2024 – blue/pink/white – in recognition of Transgender Awareness Week",
+		"Thursday, November 21, 2024 – purple – in recognition of World Pancreatic Cancer Day",
+	}, time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC))
+
+	keys := []string{}
+	for _, event := range got.Events {
+		keys = append(keys, event.Key())
+	}
+	require.Equal(t, []string{"2024-11-20", "2024-11-20#2", "2024-11-21"}, keys)
+}
+
 func Test_convertToTimestamps(t *testing.T) {
 	loc, err := time.LoadLocation("America/Los_Angeles")
 	require.NoError(t, err)
 	type args struct {
-		dateString string
+		dateString    string
+		scheduleMonth time.Time
 	}
 	tests := []struct {
 		name    string
@@ -44,21 +233,24 @@ func Test_convertToTimestamps(t *testing.T) {
 		{
 			name: "parses single date with year",
 			args: args{
-				dateString: "Saturday, November 2, 2024",
+				scheduleMonth: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				dateString:    "Saturday, November 2, 2024",
 			},
 			want: []time.Time{time.Date(2024, 11, 2, 0, 0, 0, 0, loc)},
 		},
 		{
 			name: "parses single date without year",
 			args: args{
-				dateString: "Saturday, November 2",
+				scheduleMonth: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				dateString:    "Saturday, November 2",
 			},
 			want: []time.Time{time.Date(2024, 11, 2, 0, 0, 0, 0, loc)},
 		},
 		{
 			name: "parses date range using 'and' phrasing",
 			args: args{
-				dateString: "Sunday, November 3 and Monday, November 4, 2024",
+				scheduleMonth: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				dateString:    "Sunday, November 3 and Monday, November 4, 2024",
 			},
 			want: []time.Time{
 				time.Date(2024, 11, 3, 0, 0, 0, 0, loc),
@@ -68,17 +260,57 @@ func Test_convertToTimestamps(t *testing.T) {
 		{
 			name: "parses date range using 'through' phrasing",
 			args: args{
-				dateString: "Sunday, November 3 through Monday, November 4, 2024",
+				scheduleMonth: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				dateString:    "Sunday, November 3 through Monday, November 4, 2024",
 			},
 			want: []time.Time{
 				time.Date(2024, 11, 3, 0, 0, 0, 0, loc),
 				time.Date(2024, 11, 4, 0, 0, 0, 0, loc),
 			},
 		},
+		{
+			name: "expands 'through' phrasing into every night of the range",
+			args: args{
+				scheduleMonth: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				dateString:    "Thursday, November 28 through Saturday, November 30, 2024",
+			},
+			want: []time.Time{
+				time.Date(2024, 11, 28, 0, 0, 0, 0, loc),
+				time.Date(2024, 11, 29, 0, 0, 0, 0, loc),
+				time.Date(2024, 11, 30, 0, 0, 0, 0, loc),
+			},
+		},
+		{
+			name: "takes the year of dates without one from the schedule month",
+			args: args{
+				scheduleMonth: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+				dateString:    "Monday, March 17",
+			},
+			want: []time.Time{time.Date(2025, 3, 17, 0, 0, 0, 0, loc)},
+		},
+		{
+			name: "rolls January dates on a December schedule into the next year",
+			args: args{
+				scheduleMonth: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+				dateString:    "Tuesday, December 31 and Wednesday, January 1",
+			},
+			want: []time.Time{
+				time.Date(2024, 12, 31, 0, 0, 0, 0, loc),
+				time.Date(2025, 1, 1, 0, 0, 0, 0, loc),
+			},
+		},
+		{
+			name: "range ending before it starts fails",
+			args: args{
+				scheduleMonth: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				dateString:    "Saturday, November 30 through Thursday, November 28, 2024",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := convertToTimestamps(tt.args.dateString)
+			got, err := convertToTimestamps(tt.args.dateString, tt.args.scheduleMonth)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("convertToTimestamps() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -98,7 +330,7 @@ func Test_parseSingleDate(t *testing.T) {
 		dateStr           string
 		layout            string
 		layoutWithoutYear string
-		year              int
+		scheduleMonth     time.Time
 	}
 	tests := []struct {
 		name    string
@@ -112,7 +344,7 @@ func Test_parseSingleDate(t *testing.T) {
 				dateStr:           "Saturday, November 2, 2024",
 				layout:            "Monday, January 2, 2006",
 				layoutWithoutYear: "Monday, January 2",
-				year:              2024,
+				scheduleMonth:     time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
 			},
 			want: time.Date(2024, 11, 2, 0, 0, 0, 0, loc),
 		},
@@ -122,14 +354,14 @@ func Test_parseSingleDate(t *testing.T) {
 				dateStr:           "Saturday, November 2",
 				layout:            "Monday, January 2, 2006",
 				layoutWithoutYear: "Monday, January 2",
-				year:              2024,
+				scheduleMonth:     time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
 			},
 			want: time.Date(2024, 11, 2, 0, 0, 0, 0, loc),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseSingleDate(tt.args.dateStr, tt.args.layout, tt.args.layoutWithoutYear, tt.args.year)
+			got, err := parseSingleDate(tt.args.dateStr, tt.args.layout, tt.args.layoutWithoutYear, tt.args.scheduleMonth)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseSingleDate() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -141,6 +373,58 @@ func Test_parseSingleDate(t *testing.T) {
 	}
 }
 
+func Test_inferYear(t *testing.T) {
+	type args struct {
+		month         time.Month
+		scheduleMonth time.Time
+	}
+	tests := []struct {
+		name string
+		args args
+		want int
+	}{
+		{
+			name: "same month uses the schedule year",
+			args: args{
+				month:         time.November,
+				scheduleMonth: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: 2024,
+		},
+		{
+			name: "following month uses the schedule year",
+			args: args{
+				month:         time.December,
+				scheduleMonth: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: 2024,
+		},
+		{
+			name: "january on a december schedule uses the next year",
+			args: args{
+				month:         time.January,
+				scheduleMonth: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: 2025,
+		},
+		{
+			name: "december on a january schedule uses the previous year",
+			args: args{
+				month:         time.December,
+				scheduleMonth: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: 2024,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferYear(tt.args.month, tt.args.scheduleMonth); got != tt.want {
+				t.Errorf("inferYear() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_transformDescription(t *testing.T) {
 	type args struct {
 		description string
@@ -185,6 +469,67 @@ func Test_transformDescription(t *testing.T) {
 	}
 }
 
+func Test_parseColors(t *testing.T) {
+	type args struct {
+		colors string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []model.Color
+	}{
+		{
+			name: "maps a single color",
+			args: args{
+				colors: "Blue",
+			},
+			want: []model.Color{{Name: "blue", Hex: "#0057B8", Emoji: "💙"}},
+		},
+		{
+			name: "maps colors separated by slashes",
+			args: args{
+				colors: "Poppy/Navy",
+			},
+			want: []model.Color{
+				{Name: "poppy", Hex: "#E35335", Emoji: "❤️"},
+				{Name: "navy", Hex: "#1F2A44", Emoji: "💙"},
+			},
+		},
+		{
+			name: "maps synonyms to their canonical color",
+			args: args{
+				colors: "shades of amber",
+			},
+			want: []model.Color{{Name: "amber", Hex: "#FFBF00", Emoji: "🧡"}},
+		},
+		{
+			name: "maps colors separated by 'and'",
+			args: args{
+				colors: "pink and  Yellow",
+			},
+			want: []model.Color{
+				{Name: "pink", Hex: "#F5A9B8", Emoji: "🩷"},
+				{Name: "yellow", Hex: "#FFE135", Emoji: "💛"},
+			},
+		},
+		{
+			name: "flags colors missing from the palette",
+			args: args{
+				colors: "orange/Chartreuse",
+			},
+			want: []model.Color{
+				{Name: "orange", Hex: "#F77F00", Emoji: "🧡"},
+				{Name: "chartreuse", Unknown: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, parseColors(tt.args.colors))
+		})
+	}
+}
+
 func Test_transformColors(t *testing.T) {
 	type args struct {
 		colors string
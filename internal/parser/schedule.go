@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// noticeRE matches the notice that the schedule may change, e.g. "(subject to change)" in the heading.
+var noticeRE = regexp.MustCompile(`(?i)\(?\s*subject to change\s*\)?`)
+
+// Schedule is a lighting schedule page parsed into its parts.
+type Schedule struct {
+	// Month is the first of the month the schedule was published for, as named by its heading.
+	Month time.Time
+	// Locator names the strategy that found the schedule on the page.
+	Locator  string
+	Heading  string
+	Notice   string
+	Intro    string
+	Events   []model.Event
+	Unparsed []model.UnparsedLine
+	Links    []Link
+}
+
+type Link struct {
+	Text string
+	URL  string
+}
+
+// ParseSchedule parses a full sf.gov City Hall page into its lighting schedule, using DefaultLocators to
+// find it.
+func ParseSchedule(r io.Reader) (Schedule, error) {
+	return ParseScheduleWith(r, DefaultLocators())
+}
+
+// ParseScheduleWith parses a page into its lighting schedule, trying each locator in turn. A locator only
+// matches when the section it finds has a schedule heading and at least one listing, so that a selector
+// pointing at the wrong section falls through to the next strategy.
+func ParseScheduleWith(r io.Reader, locators []Locator) (Schedule, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("failed to read page: %w", err)
+	}
+	failures := []string{}
+	for _, locator := range locators {
+		section := locator.Find(doc)
+		if section.Length() == 0 {
+			failures = append(failures, fmt.Sprintf("%s: no section found", locator.Name))
+			continue
+		}
+		schedule, err := parseSection(section)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", locator.Name, err))
+			continue
+		}
+		if len(schedule.Events) == 0 {
+			failures = append(failures, fmt.Sprintf("%s: no events found", locator.Name))
+			continue
+		}
+		schedule.Locator = locator.Name
+		return schedule, nil
+	}
+	return Schedule{}, fmt.Errorf("lighting schedule not found on page (%s)", strings.Join(failures, "; "))
+}
+
+// parseSection parses the section holding the schedule.
+// Paragraphs before the first listing form the intro, paragraphs holding only a link are collected as links,
+// and every other paragraph is parsed as a listing.
+func parseSection(section *goquery.Selection) (Schedule, error) {
+	var err error
+	schedule := Schedule{
+		Heading:  cleanText(section.Find("h4").First().Text()),
+		Events:   []model.Event{},
+		Unparsed: []model.UnparsedLine{},
+		Links:    []Link{},
+	}
+	schedule.Month, err = ParseScheduleMonth(schedule.Heading)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("failed to parse schedule heading: %w", err)
+	}
+	if notice := noticeRE.FindString(schedule.Heading); notice != "" {
+		schedule.Notice = strings.Trim(notice, " ()")
+	}
+
+	section.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		schedule.Links = append(schedule.Links, Link{Text: cleanText(a.Text()), URL: href})
+	})
+
+	intro := []string{}
+	lines := []string{}
+	section.Find("p").Each(func(_ int, p *goquery.Selection) {
+		text := cleanText(p.Text())
+		switch {
+		case text == "":
+		case isLinkOnly(p, text):
+		case len(lines) == 0 && scanDate(text) == 0:
+			if notice := noticeRE.FindString(text); notice != "" && schedule.Notice == "" {
+				schedule.Notice = strings.Trim(notice, " ()")
+			}
+			intro = append(intro, text)
+		default:
+			lines = append(lines, text)
+		}
+	})
+	schedule.Intro = strings.Join(intro, "\n")
+
+	result := ParseEvents(lines, schedule.Month)
+	schedule.Events = result.Events
+	schedule.Unparsed = result.Rejected
+	return schedule, nil
+}
+
+// ParseScheduleMonth returns the first of the month named by a schedule heading such as
+// "November 2024 scheduled lighting events (subject to change)".
+func ParseScheduleMonth(heading string) (time.Time, error) {
+	parts := strings.Fields(heading)
+	if len(parts) < 2 {
+		return time.Time{}, fmt.Errorf("heading %q does not start with a month and year", heading)
+	}
+	return time.Parse("January 2006", fmt.Sprintf("%s %s", parts[0], parts[1]))
+}
+
+// cleanText replaces non-breaking spaces and trims the text of an element.
+func cleanText(text string) string {
+	return strings.TrimSpace(strings.ReplaceAll(text, "\u00a0", " "))
+}
+
+// isLinkOnly reports whether a paragraph holds nothing but a link, e.g. "Learn more about City Hall's exterior
+// lighting and see past lighting schedules."
+func isLinkOnly(p *goquery.Selection, text string) bool {
+	links := cleanText(p.Find("a").Text())
+	return links != "" && strings.Trim(strings.TrimPrefix(text, links), " .") == ""
+}
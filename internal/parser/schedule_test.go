@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	file, err := os.Open("test-fixtures/san-francisco-city-hall.html")
+	require.NoError(t, err)
+	defer file.Close()
+
+	got, err := ParseSchedule(file)
+	require.NoError(t, err)
+
+	require.Equal(t, time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), got.Month)
+	require.Equal(t, "November 2024 scheduled lighting events (subject to change)", got.Heading)
+	require.Equal(t, "subject to change", got.Notice)
+	require.Equal(t, "City Hall will be lit in special lighting on the following days in November 2024:", got.Intro)
+	require.Empty(t, got.Unparsed)
+	require.Len(t, got.Events, 16)
+	require.Equal(t, "Saturday, November 2, 2024", got.Events[0].DateString)
+	require.Equal(t, "Thursday, November 28 through Friday, November 29, 2024", got.Events[15].DateString)
+	require.Equal(t, []Link{
+		{
+			Text: "Learn more about City Hall's exterior lighting and see past lighting schedules",
+			URL:  "https://sf.gov/information/about-city-halls-exterior-lighting",
+		},
+	}, got.Links)
+}
+
+func TestParseSchedule_sections(t *testing.T) {
+	page := func(content string) string {
+		return `<html><body><div id="block-sfgovpl-content"><article><div class="sfgov-section-container">
+			<div class="group--left"><div class="sfgov-section sfgov-section-getting-here"><div>
+			<div class="field field--type-entity-reference-revisions __getting-here-items field__items">
+			<div></div><div></div><div></div><div></div>
+			<div><details><summary>Lighting schedule</summary><div><div>` + content + `</div></div></details></div>
+			</div></div></div></div></div></article></div></body></html>`
+	}
+	tests := []struct {
+		name         string
+		page         string
+		wantEvents   int
+		wantUnparsed []string
+		wantNotice   string
+		wantErr      string
+	}{
+		{
+			name: "quarantines listings that fail to parse",
+			page: page(`<h4>December 2024 scheduled lighting events</h4>
+				<p>City Hall will be lit in special lighting on the following days in December 2024:</p>
+				<p>Sunday, December 1, 2024 – red – in recognition of World AIDS Day</p>
+				<p>Tuesday, December 31 through Wednesday, January 1 – gold – in recognition of the New Year</p>
+				<p>Please check back for more lighting events</p>`),
+			wantEvents:   2,
+			wantUnparsed: []string{"Please check back for more lighting events"},
+		},
+		{
+			name: "reads the notice from an intro paragraph",
+			page: page(`<h4>December 2024 scheduled lighting events</h4>
+				<p>All lighting is subject to change.</p>
+				<p>Sunday, December 1, 2024 – red – in recognition of World AIDS Day</p>`),
+			wantEvents: 1,
+			wantNotice: "subject to change",
+		},
+		{
+			name:    "fails without a month in the heading",
+			page:    page(`<h4>Lighting</h4><p>Sunday, December 1, 2024 – red – in recognition of World AIDS Day</p>`),
+			wantErr: "failed to parse schedule heading",
+		},
+		{
+			name:    "fails without a schedule section",
+			page:    `<html><body><p>Sunday, December 1, 2024 – red – World AIDS Day</p></body></html>`,
+			wantErr: "lighting schedule not found on page",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSchedule(strings.NewReader(tt.page))
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got.Events, tt.wantEvents)
+			require.Equal(t, tt.wantNotice, got.Notice)
+			unparsed := []string{}
+			for _, line := range got.Unparsed {
+				unparsed = append(unparsed, line.RawEventString)
+			}
+			if tt.wantUnparsed == nil {
+				tt.wantUnparsed = []string{}
+			}
+			require.Equal(t, tt.wantUnparsed, unparsed)
+		})
+	}
+}
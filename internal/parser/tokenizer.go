@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A schedule line has the grammar
+//
+//	line        = date delimiter color delimiter description
+//	date        = date-token { [connector] date-token }
+//	delimiter   = en or em dash, or a hyphen next to whitespace
+//
+// The date is anchored at the start of the line and the color is the next delimited segment. Everything after
+// the color is the description, so dashes inside it ("Gender-Based Violence") are kept as written.
+const monthNames = `jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sept?(?:ember)?|` +
+	`oct(?:ober)?|nov(?:ember)?|dec(?:ember)?`
+
+var (
+	// dateWordRE matches weekday and month names, in full or abbreviated.
+	dateWordRE = regexp.MustCompile(`^(?i)(?:(?:mon|tues?|wed(?:nes)?|thu(?:rs?)?|fri|sat(?:ur)?|sun)(?:day)?|` +
+		monthNames + `)\b\.?`)
+	// dateNumberRE matches days, years and numeric dates such as "11/6" or "11/6/2024".
+	dateNumberRE = regexp.MustCompile(`^\d{1,4}(?:/\d{1,2}(?:/\d{2,4})?)?(?:st|nd|rd|th)?\b`)
+	// dateConnectorRE matches the words and punctuation joining date tokens.
+	dateConnectorRE = regexp.MustCompile(`^(?i)(?:,|&|(?:and|through|thru|to)\b)`)
+	// dateRangeDashRE matches a dash after a numeric date token that is followed by another numeric token or a
+	// month, as in "November 28-29", "11/1 - 11/6" or "November 30 - December 2". The dash is the first group.
+	dateRangeDashRE = regexp.MustCompile(`^(\s*[-–—]\s*)(?:\d|(?i:` + monthNames + `)\b)`)
+	// delimiterRE matches the separator between fields. Runs of whitespace are accepted after the date, as
+	// some schedules lay the fields out in columns.
+	delimiterRE = regexp.MustCompile(`^(?:\s*[–—]\s*|\s+-\s*|-\s+|\s{2,}|\t+)`)
+	// colorEndRE matches the delimiter that ends the color. A hyphen inside a word ("blue-green") does not.
+	colorEndRE = regexp.MustCompile(`\s*[–—]\s*|\s+-\s*|-\s+`)
+)
+
+// tokens are the fields of a schedule line.
+type tokens struct {
+	date        string
+	color       string
+	description string
+}
+
+// tokenize splits a schedule line into its date, color and description fields.
+func tokenize(line string) (tokens, error) {
+	dateEnd := scanDate(line)
+	if dateEnd == 0 {
+		return tokens{}, &ParseError{
+			Stage:      StageSplit,
+			Line:       line,
+			Offending:  line,
+			Suggestion: splitSuggestion,
+			Err:        fmt.Errorf("line does not start with a date"),
+		}
+	}
+	rest := line[dateEnd:]
+	delimiter := delimiterRE.FindString(rest)
+	if delimiter == "" {
+		return tokens{}, &ParseError{
+			Stage:      StageSplit,
+			Line:       line,
+			Offending:  strings.TrimSpace(rest),
+			Suggestion: splitSuggestion,
+			Err:        fmt.Errorf("expected a delimiter after the date"),
+		}
+	}
+	rest = rest[len(delimiter):]
+	colorEnd := colorEndRE.FindStringIndex(rest)
+	if colorEnd == nil {
+		return tokens{}, &ParseError{
+			Stage:      StageSplit,
+			Line:       line,
+			Offending:  strings.TrimSpace(rest),
+			Suggestion: splitSuggestion,
+			Err:        fmt.Errorf("expected a delimiter after the color"),
+		}
+	}
+	return tokens{
+		date:        strings.TrimSpace(line[:dateEnd]),
+		color:       strings.TrimSpace(rest[:colorEnd[0]]),
+		description: strings.TrimSpace(rest[colorEnd[1]:]),
+	}, nil
+}
+
+// scanDate returns the length of the date at the start of line, or 0 if line does not start with a date.
+// Connectors are only part of the date when another date token follows them.
+func scanDate(line string) int {
+	end, pos := 0, 0
+	for pos < len(line) {
+		rest := line[pos:]
+		trimmed := strings.TrimLeft(rest, " \t")
+		skip := len(rest) - len(trimmed)
+		if m := dateWordRE.FindString(trimmed); m != "" {
+			pos += skip + len(m)
+			end = pos
+			continue
+		}
+		if m := dateNumberRE.FindString(trimmed); m != "" {
+			pos += skip + len(m)
+			end = pos
+			continue
+		}
+		if m := dateConnectorRE.FindString(trimmed); m != "" {
+			pos += skip + len(m)
+			continue
+		}
+		if m := dateRangeDashRE.FindStringSubmatch(rest); m != nil && end > 0 && isDigit(line[end-1]) {
+			// leave the date token for the next iteration
+			pos += len(m[1])
+			continue
+		}
+		break
+	}
+	return end
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
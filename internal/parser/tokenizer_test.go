@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tokenize(t *testing.T) {
+	type args struct {
+		line string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      tokens
+		wantErr   bool
+		errString string
+	}{
+		{
+			name: "splits fields separated by en dashes",
+			args: args{
+				line: "Monday, November 18, 2024 – purple – in recognition of World Prematurity Day",
+			},
+			want: tokens{
+				date:        "Monday, November 18, 2024",
+				color:       "purple",
+				description: "in recognition of World Prematurity Day",
+			},
+		},
+		{
+			name: "splits fields separated by hyphens",
+			args: args{
+				line: "Friday, November 8, 2024 - Poppy/Navy - in recognition of the BayFC making the Playoffs",
+			},
+			want: tokens{
+				date:        "Friday, November 8, 2024",
+				color:       "Poppy/Navy",
+				description: "in recognition of the BayFC making the Playoffs",
+			},
+		},
+		{
+			name: "keeps hyphenated words in the description",
+			args: args{
+				line: "Monday, November 25, 2024 – orange – in recognition of the 16 Days of Activism Against Gender-Based Violence",
+			},
+			want: tokens{
+				date:        "Monday, November 25, 2024",
+				color:       "orange",
+				description: "in recognition of the 16 Days of Activism Against Gender-Based Violence",
+			},
+		},
+		{
+			name: "keeps words joined by several hyphens in the description",
+			args: args{
+				line: "Tuesday, March 16, 2021 – red/yellow – in recognition of the Stop-AAPI-Hate campaign",
+			},
+			want: tokens{
+				date:        "Tuesday, March 16, 2021",
+				color:       "red/yellow",
+				description: "in recognition of the Stop-AAPI-Hate campaign",
+			},
+		},
+		{
+			name: "keeps spaced dashes in the description",
+			args: args{
+				line: "Saturday, June 29, 2024 – rainbow – in recognition of Pride – the 54th annual celebration - and Trans March",
+			},
+			want: tokens{
+				date:        "Saturday, June 29, 2024",
+				color:       "rainbow",
+				description: "in recognition of Pride – the 54th annual celebration - and Trans March",
+			},
+		},
+		{
+			name: "keeps hyphenated colors",
+			args: args{
+				line: "Friday, April 5, 2024 – blue-green – in recognition of Earth Month",
+			},
+			want: tokens{
+				date:        "Friday, April 5, 2024",
+				color:       "blue-green",
+				description: "in recognition of Earth Month",
+			},
+		},
+		{
+			name: "splits fields separated by mixed dashes and no spaces around an en dash",
+			args: args{
+				line: "Thursday, November 14, 2024–Blue - SFDPH \"Living Proof\" campaign",
+			},
+			want: tokens{
+				date:        "Thursday, November 14, 2024",
+				color:       "Blue",
+				description: "SFDPH \"Living Proof\" campaign",
+			},
+		},
+		{
+			name: "keeps 'and' and 'through' dates together",
+			args: args{
+				line: "Thursday, November 28 through Friday, November 29, 2024 – shades of amber – in recognition of the Thanksgiving Holiday",
+			},
+			want: tokens{
+				date:        "Thursday, November 28 through Friday, November 29, 2024",
+				color:       "shades of amber",
+				description: "in recognition of the Thanksgiving Holiday",
+			},
+		},
+		{
+			name: "keeps a dash between numeric dates in the date",
+			args: args{
+				line: "11/1 - 11/6      Red/white/blue – Election!",
+			},
+			want: tokens{
+				date:        "11/1 - 11/6",
+				color:       "Red/white/blue",
+				description: "Election!",
+			},
+		},
+		{
+			name: "keeps a dash before a month in the date",
+			args: args{
+				line: "November 30 - December 2 – Red – in recognition of World AIDS Day",
+			},
+			want: tokens{
+				date:        "November 30 - December 2",
+				color:       "Red",
+				description: "in recognition of World AIDS Day",
+			},
+		},
+		{
+			name: "line not starting with a date fails",
+			args: args{
+				line: "City Hall will be lit in special lighting on the following days in November 2024:",
+			},
+			wantErr:   true,
+			errString: "line does not start with a date",
+		},
+		{
+			name: "line without a delimiter after the color fails",
+			args: args{
+				line: "Monday, November 18, 2024 – purple in recognition of World Prematurity Day",
+			},
+			wantErr:   true,
+			errString: "expected a delimiter after the color",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tokenize(tt.args.line)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("tokenize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				require.Contains(t, err.Error(), tt.errString)
+				return
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_tokenize_fixture(t *testing.T) {
+	file, err := os.Open("test-fixtures/san-francisco-city-hall.html")
+	require.NoError(t, err)
+	defer file.Close()
+	doc, err := goquery.NewDocumentFromReader(file)
+	require.NoError(t, err)
+
+	lines := []string{}
+	doc.Find("details").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return strings.TrimSpace(s.Find("summary").Text()) == "Lighting schedule"
+	}).Find("p").Each(func(_ int, s *goquery.Selection) {
+		line := strings.TrimSpace(strings.ReplaceAll(s.Text(), " ", ""))
+		if line != "" && !strings.HasPrefix(line, "City Hall will be lit") && !strings.HasPrefix(line, "Learn more") {
+			lines = append(lines, line)
+		}
+	})
+	require.Len(t, lines, 16)
+
+	for _, line := range lines {
+		got, err := tokenize(line)
+		require.NoError(t, err, line)
+		require.NotEmpty(t, got.date, line)
+		require.NotEmpty(t, got.color, line)
+		require.NotEmpty(t, got.description, line)
+	}
+	got, err := tokenize(lines[14])
+	require.NoError(t, err)
+	require.Equal(t, tokens{
+		date:  "Monday, November 25, 2024",
+		color: "orange",
+		description: "in recognition of the International Day of Elimination of Violence Against Women; " +
+			"this is part of the annual United Nations Campaign: 16 Days of Activism Against Gender Based Violence",
+	}, got)
+}
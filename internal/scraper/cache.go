@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry is the last full response for a URL, kept so that a 304 Not Modified can be answered
+// from disk.
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Body         string    `json:"body"`
+}
+
+func cacheFilename(dir, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// readCacheEntry returns the cached response for url, or nil when caching is disabled or nothing is cached.
+func readCacheEntry(dir, url string) (*cacheEntry, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(cacheFilename(dir, url))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry cacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	if entry.URL != url {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func writeCacheEntry(dir string, entry cacheEntry) error {
+	if dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cacheFilename(dir, entry.URL), data, 0644)
+}
@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"os"
+
+	"city-hall-lights/internal/datadir"
+	"city-hall-lights/internal/parser"
+)
+
+// Config locates the lighting schedule. Each field can be overridden through the environment, so that a
+// change to sf.gov can be worked around without a release.
+type Config struct {
+	// URL is the page listing the schedule. Overridden by LIGHTING_SCHEDULE_URL.
+	URL string
+	// Selector is the CSS selector tried first to find the schedule section. Overridden by
+	// LIGHTING_SCHEDULE_SELECTOR.
+	Selector string
+	// Summary is the <summary> heading of the section, tried when the selector fails. Overridden by
+	// LIGHTING_SCHEDULE_SUMMARY.
+	Summary string
+	// CacheDir keeps the last response so the page can be revalidated instead of downloaded again. Empty
+	// disables caching. Defaults to the cache directory of the data directory, overridden by
+	// LIGHTING_SCHEDULE_CACHE_DIR.
+	CacheDir string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		URL:      lightingScheduleURL,
+		Selector: parser.DefaultSelector,
+		Summary:  parser.DefaultSummary,
+		CacheDir: datadir.Default().Cache(),
+	}
+}
+
+// ConfigFromEnv returns the default config, caching in the data directory dir, with any overrides set in the
+// environment.
+func ConfigFromEnv(dir datadir.Dir) Config {
+	cfg := DefaultConfig()
+	cfg.CacheDir = dir.Cache()
+	if url := os.Getenv("LIGHTING_SCHEDULE_URL"); url != "" {
+		cfg.URL = url
+	}
+	if selector := os.Getenv("LIGHTING_SCHEDULE_SELECTOR"); selector != "" {
+		cfg.Selector = selector
+	}
+	if summary := os.Getenv("LIGHTING_SCHEDULE_SUMMARY"); summary != "" {
+		cfg.Summary = summary
+	}
+	if cacheDir := os.Getenv("LIGHTING_SCHEDULE_CACHE_DIR"); cacheDir != "" {
+		cfg.CacheDir = cacheDir
+	}
+	return cfg
+}
+
+// Locators returns the strategies used to find the schedule, in the order they are tried.
+func (c Config) Locators() []parser.Locator {
+	locators := []parser.Locator{}
+	if c.Selector != "" {
+		locators = append(locators, parser.SelectorLocator(c.Selector))
+	}
+	if c.Summary != "" {
+		locators = append(locators, parser.SummaryLocator(c.Summary))
+	}
+	return locators
+}
@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"city-hall-lights/internal/parser"
+)
+
+// ScrapeFile parses a saved copy of the lighting schedule page, e.g. a test fixture or a Wayback Machine export.
+func ScrapeFile(path string, cfg Config) (parser.Schedule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return parser.Schedule{}, err
+	}
+	defer file.Close()
+	schedule, err := parser.ParseScheduleWith(file, cfg.Locators())
+	if err != nil {
+		return parser.Schedule{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return schedule, nil
+}
+
+// ScrapeDir parses every saved page under dir, keeping one schedule per month. Pages are read in name order,
+// so when several snapshots of a month are saved the last one wins; Wayback Machine exports are named by
+// capture time, which makes that the most recent capture. Pages without a schedule are returned as errors
+// alongside the schedules that were found.
+func ScrapeDir(dir string, cfg Config) ([]parser.Schedule, []error) {
+	paths := []string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if !d.IsDir() && (ext == ".html" || ext == ".htm") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, []error{err}
+	}
+	sort.Strings(paths)
+
+	byMonth := make(map[string]parser.Schedule)
+	months := []string{}
+	errs := []error{}
+	for _, path := range paths {
+		schedule, err := ScrapeFile(path, cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		key := schedule.Month.Format("2006-01")
+		if _, ok := byMonth[key]; !ok {
+			months = append(months, key)
+		}
+		byMonth[key] = schedule
+	}
+	sort.Strings(months)
+
+	schedules := []parser.Schedule{}
+	for _, month := range months {
+		schedules = append(schedules, byMonth[month])
+	}
+	return schedules, errs
+}
+
+// ScrapePath parses a single saved page or every saved page in a directory.
+func ScrapePath(path string, cfg Config) ([]parser.Schedule, []error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if info.IsDir() {
+		return ScrapeDir(path, cfg)
+	}
+	schedule, err := ScrapeFile(path, cfg)
+	if err != nil {
+		return nil, []error{err}
+	}
+	return []parser.Schedule{schedule}, nil
+}
@@ -0,0 +1,62 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const fixturePage = "../parser/test-fixtures/san-francisco-city-hall.html"
+
+func TestScrapeFile(t *testing.T) {
+	schedule, err := ScrapeFile(fixturePage, DefaultConfig())
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), schedule.Month)
+	require.NotEmpty(t, schedule.Events)
+}
+
+func TestScrapePath(t *testing.T) {
+	page, err := os.ReadFile(fixturePage)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "web"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web", "20241101000000.html"), page, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web", "20241115000000.htm"), page, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a page"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.html"), []byte("<html></html>"), 0644))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantMonths int
+		wantErrs   int
+	}{
+		{
+			name:       "single file",
+			path:       fixturePage,
+			wantMonths: 1,
+		},
+		{
+			name:       "directory keeps one schedule per month",
+			path:       dir,
+			wantMonths: 1,
+			wantErrs:   1,
+		},
+		{
+			name:     "missing path",
+			path:     filepath.Join(dir, "missing"),
+			wantErrs: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedules, errs := ScrapePath(tt.path, DefaultConfig())
+			require.Len(t, schedules, tt.wantMonths)
+			require.Len(t, errs, tt.wantErrs)
+		})
+	}
+}
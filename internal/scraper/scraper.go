@@ -1,77 +1,134 @@
 package scraper
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"strings"
+	"net/http"
 	"time"
 
-	"city-hall-lights/internal/model"
 	"city-hall-lights/internal/parser"
 	"github.com/gocolly/colly/v2"
 )
 
-const (
-	lightingScheduleURL        = "https://www.sf.gov/location/san-francisco-city-hall"
-	excludeFirstListElemString = "City Hall will be lit"
-	excludeLastListElemString  = "Learn more about City Hall's exterior lighting and see past lighting schedules."
-	selector                   = `#block-sfgovpl-content > article > div.sfgov-section-container > 
-								  div.group--left > div.sfgov-section.sfgov-section-getting-here > div > 
-								  div.field.field--type-entity-reference-revisions.__getting-here-items.field__items > 
-								  div:nth-child(5) > details > div > div`
-)
+const lightingScheduleURL = "https://www.sf.gov/location/san-francisco-city-hall"
 
-func Scrape() ([]model.Event, error) {
-	events := make([]model.Event, 0)
+// Page is a single download of the lighting schedule page, shared by the update check and the scrape.
+type Page struct {
+	URL  string
+	Body []byte
+	// Hash is the hex encoded SHA-256 of Body, so that runs can tell whether the page changed at all.
+	Hash string
+	// Month is the month named in the schedule heading.
+	Month    time.Time
+	Schedule parser.Schedule
+	// Cached is set when the server answered 304 Not Modified and Body was read from the cache.
+	Cached bool
+	// CacheErr is set when the cached response could not be read, in which case the page was downloaded in full,
+	// or when the downloaded page could not be cached. Neither fails the fetch.
+	CacheErr  error
+	FetchedAt time.Time
+}
 
-	c := colly.NewCollector()
+// Fetch downloads the lighting schedule page once and parses it. When cfg.CacheDir is set the last
+// response is kept on disk and revalidated with If-None-Match/If-Modified-Since, so an unchanged page
+// costs a 304 instead of a full download. The page is returned even when the schedule can't be parsed.
+func Fetch(cfg Config) (Page, error) {
+	page := Page{URL: cfg.URL}
+	cached, err := readCacheEntry(cfg.CacheDir, cfg.URL)
+	if err != nil {
+		page.CacheErr = err
+		cached = nil
+	}
 
-	c.OnHTML(selector, func(e *colly.HTMLElement) {
-		e.ForEach("p", func(_ int, el *colly.HTMLElement) {
-			parsedEvent := model.Event{}
-			if el.Text != "\u00a0" && !strings.Contains(el.Text, excludeFirstListElemString) &&
-				!strings.Contains(el.Text, excludeLastListElemString) {
-				parsedEvent = parser.ParseEvent(el.Text)
-				events = append(events, parsedEvent)
+	var respErr error
+	c := colly.NewCollector(colly.ParseHTTPErrorResponse())
+	c.OnRequest(func(r *colly.Request) {
+		if cached == nil {
+			return
+		}
+		if cached.ETag != "" {
+			r.Headers.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			r.Headers.Set("If-Modified-Since", cached.LastModified)
+		}
+	})
+	c.OnResponse(func(r *colly.Response) {
+		switch {
+		case r.StatusCode == http.StatusNotModified && cached != nil:
+			page.Body = []byte(cached.Body)
+			page.Cached = true
+			page.FetchedAt = cached.FetchedAt
+		case r.StatusCode == http.StatusOK:
+			page.Body = r.Body
+			page.FetchedAt = time.Now()
+			err := writeCacheEntry(cfg.CacheDir, cacheEntry{
+				URL:          cfg.URL,
+				ETag:         r.Headers.Get("ETag"),
+				LastModified: r.Headers.Get("Last-Modified"),
+				FetchedAt:    page.FetchedAt,
+				Body:         string(r.Body),
+			})
+			if err != nil {
+				page.CacheErr = errors.Join(page.CacheErr, fmt.Errorf("failed to cache response: %w", err))
 			}
-		})
+		default:
+			respErr = fmt.Errorf("unexpected status %d fetching %s", r.StatusCode, cfg.URL)
+		}
 	})
 
-	if err := c.Visit(lightingScheduleURL); err != nil {
-		return nil, err
+	if err = c.Visit(cfg.URL); err != nil {
+		return page, err
 	}
-	return events, nil
+	if respErr != nil {
+		return page, respErr
+	}
+
+	sum := sha256.Sum256(page.Body)
+	page.Hash = hex.EncodeToString(sum[:])
+	page.Schedule, err = parser.ParseScheduleWith(bytes.NewReader(page.Body), cfg.Locators())
+	if err != nil {
+		return page, err
+	}
+	page.Month = page.Schedule.Month
+	return page, nil
 }
 
-func CheckPageLastUpdated() (bool, error) {
-	newDataAvailable := false
+// Scrape fetches the lighting schedule page and parses it. Lines that fail to parse are returned
+// in the schedule's Unparsed list rather than as events.
+func Scrape(cfg Config) (parser.Schedule, error) {
+	page, err := Fetch(cfg)
+	return page.Schedule, err
+}
 
-	c := colly.NewCollector()
-	lastUpdatedSelector := "#block-sfgovpl-content > article > div.sfgov-section-container > div.group--left > div.sfgov-section.sfgov-section-getting-here > div > div.field.field--type-entity-reference-revisions.__getting-here-items.field__items > div:nth-child(5) > details > div > div > h4"
-	c.OnHTML(lastUpdatedSelector, func(e *colly.HTMLElement) {
-		fmt.Println("date: ", e.Text)
-		availEvents, err := ParseAvailableEventsMonthYear(e.Text)
-		if err != nil {
-			fmt.Println(err)
-		}
-		thisMonth := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC)
-		fmt.Println("this month: ", thisMonth)
-		fmt.Println("events published for: ", availEvents)
-		if thisMonth.Equal(availEvents) {
-			fmt.Println("new data available")
-			newDataAvailable = true
-			return
-		}
-		fmt.Println("no new data")
-	})
+// PublicationStatus describes which month's schedule the lighting page has been updated with, relative to
+// the month a run is looking for.
+type PublicationStatus struct {
+	// Heading is the schedule heading as published, e.g. "November 2024 scheduled lighting events".
+	Heading string
+	// Published is the first of the month named by the heading.
+	Published time.Time
+	// IsTargetMonth is set when the page lists events for the target month.
+	IsTargetMonth bool
+	// NextMonthPosted is set when the page has already moved on to the month after the target.
+	NextMonthPosted bool
+}
 
-	if err := c.Visit(lightingScheduleURL); err != nil {
-		return newDataAvailable, err
+// CheckPublication reports which month the fetched page was published for and how that compares to the
+// month of target.
+func CheckPublication(page Page, target time.Time) PublicationStatus {
+	targetMonth := time.Date(target.Year(), target.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return PublicationStatus{
+		Heading:         page.Schedule.Heading,
+		Published:       page.Month,
+		IsTargetMonth:   page.Month.Equal(targetMonth),
+		NextMonthPosted: page.Month.Equal(targetMonth.AddDate(0, 1, 0)),
 	}
-	return true, nil
 }
 
 func ParseAvailableEventsMonthYear(rawString string) (time.Time, error) {
-	layout := "January 2006"
-	parts := strings.Split(rawString, " ")
-	return time.Parse(layout, fmt.Sprintf("%s %s", parts[0], parts[1]))
+	return parser.ParseScheduleMonth(rawString)
 }
@@ -0,0 +1,165 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetch(t *testing.T) {
+	body, err := os.ReadFile(fixturePage)
+	require.NoError(t, err)
+
+	const etag = `"nov-2024"`
+	fullResponses := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponses++
+		w.Header().Set("ETag", etag)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.URL = server.URL
+	cfg.CacheDir = t.TempDir()
+
+	first, err := Fetch(cfg)
+	require.NoError(t, err)
+	require.False(t, first.Cached)
+	require.Equal(t, time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), first.Month)
+	require.NotEmpty(t, first.Schedule.Events)
+	require.Len(t, first.Hash, 64)
+
+	second, err := Fetch(cfg)
+	require.NoError(t, err)
+	require.True(t, second.Cached)
+	require.Equal(t, first.Hash, second.Hash)
+	require.Equal(t, first.Schedule, second.Schedule)
+	require.Equal(t, 1, fullResponses)
+	require.NoError(t, second.CacheErr)
+
+	require.NoError(t, os.WriteFile(cacheFilename(cfg.CacheDir, cfg.URL), []byte("{"), 0644))
+	corrupted, err := Fetch(cfg)
+	require.NoError(t, err)
+	require.Error(t, corrupted.CacheErr)
+	require.False(t, corrupted.Cached)
+	require.Equal(t, 2, fullResponses)
+
+	cfg.CacheDir = ""
+	uncached, err := Fetch(cfg)
+	require.NoError(t, err)
+	require.False(t, uncached.Cached)
+	require.Equal(t, 3, fullResponses)
+
+	// a cache directory that can't be created doesn't fail the fetch
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	require.NoError(t, os.WriteFile(blocked, nil, 0644))
+	cfg.CacheDir = filepath.Join(blocked, "cache")
+	unwritable, err := Fetch(cfg)
+	require.NoError(t, err)
+	require.Error(t, unwritable.CacheErr)
+	require.Equal(t, first.Schedule, unwritable.Schedule)
+}
+
+func TestFetchErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.URL = server.URL
+	cfg.CacheDir = t.TempDir()
+
+	_, err := Fetch(cfg)
+	require.Error(t, err)
+}
+
+func TestCheckPublication(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("test-fixtures")))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		page    string
+		target  time.Time
+		want    PublicationStatus
+		wantErr string
+	}{
+		{
+			name:   "current month posted",
+			page:   "november-2024.html",
+			target: time.Date(2024, 11, 15, 8, 0, 0, 0, time.UTC),
+			want: PublicationStatus{
+				Heading:       "November 2024 scheduled lighting events (subject to change)",
+				Published:     time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+				IsTargetMonth: true,
+			},
+		},
+		{
+			name:   "previous month still posted",
+			page:   "november-2024.html",
+			target: time.Date(2024, 12, 1, 8, 0, 0, 0, time.UTC),
+			want: PublicationStatus{
+				Heading:   "November 2024 scheduled lighting events (subject to change)",
+				Published: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:   "next month already posted",
+			page:   "december-2024.html",
+			target: time.Date(2024, 11, 29, 8, 0, 0, 0, time.UTC),
+			want: PublicationStatus{
+				Heading:         "December 2024 scheduled lighting events (subject to change)",
+				Published:       time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+				NextMonthPosted: true,
+			},
+		},
+		{
+			name:   "next month posted across the year boundary",
+			page:   "january-2025.html",
+			target: time.Date(2024, 12, 31, 8, 0, 0, 0, time.UTC),
+			want: PublicationStatus{
+				Heading:         "January 2025 scheduled lighting events",
+				Published:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				NextMonthPosted: true,
+			},
+		},
+		{
+			name:    "one word heading",
+			page:    "heading-one-word.html",
+			target:  time.Date(2024, 11, 15, 8, 0, 0, 0, time.UTC),
+			wantErr: `heading "Lighting" does not start with a month and year`,
+		},
+		{
+			name:    "no schedule on page",
+			page:    "no-schedule.html",
+			target:  time.Date(2024, 11, 15, 8, 0, 0, 0, time.UTC),
+			wantErr: "lighting schedule not found on page",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.URL = server.URL + "/" + tt.page
+			cfg.CacheDir = ""
+
+			page, err := Fetch(cfg)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, CheckPublication(page, tt.target))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	filePerm = 0644
+	dirPerm  = 0755
+)
+
+// writeJSONAtomic writes the JSON encoding of v to filename through a temporary file in the same directory
+// that is renamed into place, so that a crash never leaves a partly written file behind. With exclusive set
+// it fails if filename already exists.
+func writeJSONAtomic(filename string, v any, exclusive bool) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	// once renamed or linked into place the temporary name no longer exists, or is a spare link
+	defer os.Remove(tmp.Name())
+
+	if err = json.NewEncoder(tmp).Encode(v); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err = os.Chmod(tmp.Name(), filePerm); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if exclusive {
+		// linking fails when filename exists, where a rename would replace it
+		err = os.Link(tmp.Name(), filename)
+	} else {
+		err = os.Rename(tmp.Name(), filename)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes a directory so that a rename into it survives a crash. Not every platform supports it, so
+// failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
+// lockName is the file in the store directory that writers lock while they read, modify and write a file.
+const lockName = ".lock"
+
+// Locker is implemented by backends that can hold their lock across several calls, such as FileStore, so that
+// a read, modify and write spanning them isn't interleaved with another writer's. Reconcile and Override run
+// under it.
+type Locker interface {
+	// Locked runs fn with the lock held, handing it a view of the backend that doesn't take the lock again.
+	Locked(fn func(b Backend) error) error
+}
+
+// locked runs fn under the lock of b, when b is a Locker.
+func locked(b Backend, fn func(b Backend) error) error {
+	locker, ok := b.(Locker)
+	if !ok {
+		return fn(b)
+	}
+	return locker.Locked(fn)
+}
+
+func (f *FileStore) Locked(fn func(b Backend) error) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	held := *f
+	held.held = true
+	return fn(&held)
+}
+
+// lock takes the store's exclusive advisory lock, blocking until other writers, in this or another process,
+// have released it. A store handed out by Locked already holds it.
+func (f *FileStore) lock() (unlock func(), err error) {
+	if f.held {
+		return func() {}, nil
+	}
+	if err = os.MkdirAll(f.path, dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return lockFile(filepath.Join(f.path, lockName))
+}
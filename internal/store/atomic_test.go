@@ -0,0 +1,157 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSONAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "months", "2024-11-01.json")
+
+	require.NoError(t, writeJSONAtomic(filename, novemberEvents(), true))
+	require.ErrorContains(t, writeJSONAtomic(filename, []model.Event{}, true), "file exists")
+	require.NoError(t, writeJSONAtomic(filename, novemberEvents()[:1], false))
+
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(filePerm), info.Mode().Perm())
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary files are cleaned up")
+
+	got, err := readEventsFromFile(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), filepath.Dir(filename))
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+}
+
+func TestFileStore_concurrentWriters(t *testing.T) {
+	month := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	path := t.TempDir()
+	const writers = 30
+
+	done := make(chan struct{})
+	readErrs := make(chan error, 1)
+	go func() {
+		// readers must only ever see a missing or a complete file
+		reader := &FileStore{path: path, today: month}
+		for {
+			select {
+			case <-done:
+				close(readErrs)
+				return
+			default:
+			}
+			if _, err := reader.ReadMonth(); err != nil && !os.IsNotExist(err) {
+				readErrs <- err
+				close(readErrs)
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	writeErrs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(day int) {
+			defer wg.Done()
+			f := &FileStore{path: path, today: month}
+			writeErrs <- errors.Join(
+				f.Create(model.Event{StartTimeStamp: month.AddDate(0, 0, day), Color: "teal"}),
+				f.RecordPost(model.Post{Night: month.AddDate(0, 0, day), URI: fmt.Sprintf("at://post/%d", day)}),
+			)
+		}(i)
+	}
+	wg.Wait()
+	close(done)
+	close(writeErrs)
+	for err := range writeErrs {
+		require.NoError(t, err)
+	}
+	require.NoError(t, <-readErrs)
+
+	f := &FileStore{path: path, today: month}
+	events, err := f.ReadMonth()
+	require.NoError(t, err)
+	require.Len(t, events, writers)
+	posts, err := f.ListPosts(month)
+	require.NoError(t, err)
+	require.Len(t, posts, writers)
+}
+
+// TestReconcile_concurrent runs the same reconcile from several writers at once. Only the first may find the
+// changes; the others must read the month it wrote.
+func TestReconcile_concurrent(t *testing.T) {
+	path := t.TempDir()
+	month := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, StoreSchedule(&FileStore{path: path, today: month}, model.Source{}, novemberEvents()))
+	scraped := novemberEvents()
+	scraped[1].Color = "purple"
+
+	const writers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Reconcile(&FileStore{path: path, today: month}, model.Source{}, scraped)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	f := &FileStore{path: path, today: month}
+	logged, err := f.ListChanges(month)
+	require.NoError(t, err)
+	require.Len(t, logged, 1)
+	revisions, err := f.ListRevisions(month)
+	require.NoError(t, err)
+	require.Len(t, revisions, len(scraped)+1)
+}
+
+// TestFileStore_concurrentProcesses runs writers in separate processes, which only the file lock keeps apart.
+func TestFileStore_concurrentProcesses(t *testing.T) {
+	if dir := os.Getenv("STORE_TEST_WRITER_DIR"); dir != "" {
+		first, _ := strconv.Atoi(os.Getenv("STORE_TEST_WRITER_FIRST"))
+		month := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+		f := &FileStore{path: dir, today: month}
+		for day := first; day < first+5; day++ {
+			require.NoError(t, f.Create(model.Event{StartTimeStamp: month.AddDate(0, 0, day), Color: "teal"}))
+		}
+		return
+	}
+
+	path := t.TempDir()
+	const processes = 6
+	cmds := []*exec.Cmd{}
+	for i := 0; i < processes; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestFileStore_concurrentProcesses$")
+		cmd.Env = append(os.Environ(), "STORE_TEST_WRITER_DIR="+path, fmt.Sprintf("STORE_TEST_WRITER_FIRST=%d", i*5))
+		require.NoError(t, cmd.Start())
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		require.NoError(t, cmd.Wait())
+	}
+
+	f := &FileStore{path: path, today: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)}
+	events, err := f.ReadMonth()
+	require.NoError(t, err)
+	require.Len(t, events, processes*5)
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"city-hall-lights/internal/datadir"
+	"city-hall-lights/internal/model"
+)
+
+// Backend is everything the bot keeps for the schedule of a month: its events, the lines that couldn't be
+// parsed, the changes made after it was first stored and every version of its events, the posts published for
+// it and the scrapes of its page.
+type Backend interface {
+	Store
+	Ledger
+	// ForMonth returns a view of the backend scoped to the schedule of month.
+	ForMonth(month time.Time) Backend
+	// Month returns the first of the month the backend is scoped to.
+	Month() time.Time
+	// HasMonth reports whether the month's schedule has been stored.
+	HasMonth() (bool, error)
+	ReadMonth() ([]model.Event, error)
+	// CreateMonth stores the month's schedule, failing with ErrExists if two of its events share a key.
+	CreateMonth(source model.Source, events []model.Event) error
+	// MonthSource returns the scrape the month's schedule was stored from.
+	MonthSource() (model.Source, error)
+	Quarantine(lines []model.UnparsedLine) error
+	ListQuarantine(date time.Time) ([]model.UnparsedLine, error)
+	LogChanges(changes []model.Change) error
+	ListChanges(date time.Time) ([]model.Change, error)
+	// RecordRevisions appends to the month's revision log, which is never rewritten.
+	RecordRevisions(revisions []model.Revision) error
+	ListRevisions(date time.Time) ([]model.Revision, error)
+	RecordScrapeRun(run model.ScrapeRun) error
+	ListScrapeRuns(date time.Time) ([]model.ScrapeRun, error)
+}
+
+// checkKeys fails with ErrExists when two of events share a key. The parser numbers listings that start on
+// the same night, so this only happens when it has a bug.
+func checkKeys(events []model.Event) error {
+	seen := make(map[string]bool)
+	for _, event := range events {
+		if seen[event.Key()] {
+			return fmt.Errorf("%w: %s is listed twice", ErrExists, event.Key())
+		}
+		seen[event.Key()] = true
+	}
+	return nil
+}
+
+var (
+	_ Backend = (*FileStore)(nil)
+	_ Backend = (*SQLiteStore)(nil)
+	_ Backend = (*GitStore)(nil)
+)
+
+const (
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+	BackendGit    = "git"
+)
+
+// Config selects the storage backend.
+type Config struct {
+	// Backend is BackendFile, BackendSQLite or BackendGit. Overridden by STORE_BACKEND.
+	Backend string
+	// Dir holds the JSON month files of the file backend, and is the repository of the git backend.
+	Dir string
+	// GitRemote is the remote the git backend pushes to after every commit, as a name or URL. Empty disables
+	// pushing. Overridden by STORE_GIT_REMOTE.
+	GitRemote string
+	// SQLitePath is the database file of the SQLite backend. Overridden by STORE_SQLITE_PATH.
+	SQLitePath string
+}
+
+// DefaultConfig keeps the store in the default data directory.
+func DefaultConfig() Config {
+	return configIn(datadir.Default())
+}
+
+func configIn(dir datadir.Dir) Config {
+	return Config{
+		Backend:    BackendFile,
+		Dir:        dir.Events(),
+		SQLitePath: dir.SQLite(),
+	}
+}
+
+// ConfigFromEnv returns the config keeping the store in the data directory dir, with any overrides set in the
+// environment.
+func ConfigFromEnv(dir datadir.Dir) Config {
+	cfg := configIn(dir)
+	if backend := os.Getenv("STORE_BACKEND"); backend != "" {
+		cfg.Backend = backend
+	}
+	if path := os.Getenv("STORE_SQLITE_PATH"); path != "" {
+		cfg.SQLitePath = path
+	}
+	if remote := os.Getenv("STORE_GIT_REMOTE"); remote != "" {
+		cfg.GitRemote = remote
+	}
+	return cfg
+}
+
+// Open opens the configured backend, scoped to the schedule of the current month.
+func Open(cfg Config) (Backend, error) {
+	switch cfg.Backend {
+	case BackendFile:
+		fs := NewFileStore().In(cfg.Dir)
+		return &fs, nil
+	case BackendSQLite:
+		return OpenSQLiteStore(cfg.SQLitePath)
+	case BackendGit:
+		return OpenGitStore(cfg.Dir, cfg.GitRemote)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q, want %q, %q or %q", cfg.Backend, BackendFile, BackendSQLite, BackendGit)
+	}
+}
@@ -8,95 +8,256 @@ import (
 	"image/png"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
+	"city-hall-lights/internal/datadir"
 	"city-hall-lights/internal/model"
 )
 
-var errorUnimplemented = fmt.Errorf("unimplemented")
-
 type FileStore struct {
 	path  string
 	today time.Time
+	// held is set on the view of a store handed out by Locked.
+	held bool
 }
 
+// NewFileStore returns a store keeping its files in the default data directory. Use In to keep them elsewhere.
 func NewFileStore() FileStore {
 	return FileStore{
-		path:  "internal/store/events",
+		path:  datadir.Default().Events(),
 		today: time.Now(),
 	}
 }
 
-func (f *FileStore) Create(events []model.Event) error {
-	return writeEventsToFile(f.today, f.path, events)
+// At returns a copy of the store that treats date as today, e.g. to import the schedule of a past month.
+func (f FileStore) At(date time.Time) FileStore {
+	f.today = date
+	return f
 }
 
-func (f *FileStore) Update(event model.Event) error {
-	return errorUnimplemented
+// ForMonth returns a copy of the store scoped to the schedule of month.
+func (f *FileStore) ForMonth(month time.Time) Backend {
+	monthStore := f.At(month)
+	return &monthStore
+}
+
+// Month returns the first of the month the store is scoped to.
+func (f *FileStore) Month() time.Time {
+	return time.Date(f.today.Year(), f.today.Month(), 1, 0, 0, 0, 0, time.UTC)
 }
 
-func (f *FileStore) Read(date time.Time) (*model.Event, error) {
-	events, err := readEventsFromFile(f.today, f.path)
+// In returns a copy of the store that keeps its files under path.
+func (f FileStore) In(path string) FileStore {
+	f.path = path
+	return f
+}
+
+// CreateMonth stores the events of the current month's schedule and the scrape they came from, failing if the
+// month is already stored.
+func (f *FileStore) CreateMonth(source model.Source, events []model.Event) error {
+	if err := checkKeys(events); err != nil {
+		return err
+	}
+	unlock, err := f.lock()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	for _, event := range events {
-		if isSameDate(event.StartTimeStamp, date) {
-			return &event, nil
+	defer unlock()
+	return writeMonthFile(f.today, f.path, MonthFile{Source: source, Events: events}, true)
+}
+
+// Create adds an event to the current month's file in date order, creating the file if needed.
+func (f *FileStore) Create(event model.Event) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := f.readMonthOrEmpty()
+	if err != nil {
+		return err
+	}
+	for _, stored := range file.Events {
+		if stored.Key() == event.Key() {
+			return fmt.Errorf("%w: %s", ErrExists, event.Key())
 		}
 	}
-	return nil, fmt.Errorf("event not found")
+	events := append(file.Events, event)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].StartTimeStamp.Before(events[j].StartTimeStamp)
+	})
+	file.Events = events
+	return writeMonthFile(f.today, f.path, file, false)
 }
 
-func (f *FileStore) List(date time.Time) ([]model.Event, error) {
-	events, err := readEventsFromFile(date, f.path)
+// Update replaces the event with the same key in the current month's file.
+func (f *FileStore) Update(event model.Event) error {
+	unlock, err := f.lock()
 	if err != nil {
-		return []model.Event{}, err
+		return err
 	}
-	return events, nil
+	defer unlock()
+	file, err := f.readMonthOrEmpty()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range file.Events {
+		if file.Events[i].Key() == event.Key() {
+			file.Events[i] = event
+			replaced = true
+		}
+	}
+	if !replaced {
+		return fmt.Errorf("%w: %s", ErrNotFound, event.Key())
+	}
+	return writeMonthFile(f.today, f.path, file, false)
 }
 
-func (f *FileStore) Delete(_ model.Event) error {
-	return errorUnimplemented
+// Delete removes the event with the same key from the current month's file.
+func (f *FileStore) Delete(event model.Event) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := f.readMonthOrEmpty()
+	if err != nil {
+		return err
+	}
+	kept := []model.Event{}
+	for _, stored := range file.Events {
+		if stored.Key() != event.Key() {
+			kept = append(kept, stored)
+		}
+	}
+	if len(kept) == len(file.Events) {
+		return fmt.Errorf("%w: %s", ErrNotFound, event.Key())
+	}
+	file.Events = kept
+	return writeMonthFile(f.today, f.path, file, false)
 }
 
-func readEventsFromFile(date time.Time, path string) ([]model.Event, error) {
-	filename := generateFilename(date, path)
-	err := validateFilename(filename)
-	if err != nil {
-		return nil, err
+// ReadMonth returns the events stored for the current month, as published on its schedule.
+func (f *FileStore) ReadMonth() ([]model.Event, error) {
+	return readEventsFromFile(f.today, f.path)
+}
+
+func (f *FileStore) readMonthOrEmpty() (MonthFile, error) {
+	file, _, err := readMonthFile(f.today, f.path)
+	if os.IsNotExist(err) {
+		return MonthFile{Events: []model.Event{}}, nil
 	}
-	file, err := os.Open(filename)
+	return file, err
+}
+
+// Read returns the event lit on date, looking in every month file that may list it.
+func (f *FileStore) Read(date time.Time) (model.Event, error) {
+	events, err := f.List(date)
 	if err != nil {
-		return nil, err
+		return model.Event{}, err
 	}
-	defer file.Close()
-	var events []model.Event
-	decoder := json.NewDecoder(file)
-	if err = decoder.Decode(&events); err != nil {
-		return nil, fmt.Errorf("failed to decode json: %w", err)
+	for _, event := range events {
+		if IsLitOn(event, date) {
+			return event, nil
+		}
 	}
-	return events, nil
+	return model.Event{}, ErrNotFound
 }
 
-func writeEventsToFile(date time.Time, path string, events []model.Event) error {
-	filename := generateFilename(date, path)
-	err := validateFilename(filename)
+// Get returns the event with the given key from the file of its month or the months either side.
+func (f *FileStore) Get(key string) (model.Event, error) {
+	date, err := time.Parse(time.DateOnly, key[:min(len(key), len(time.DateOnly))])
 	if err != nil {
-		return err
+		return model.Event{}, fmt.Errorf("invalid event key %q: %w", key, err)
+	}
+	events, _, err := f.collect(date, date, func(event model.Event) bool {
+		return event.Key() == key
+	})
+	if err != nil {
+		return model.Event{}, err
 	}
+	if len(events) == 0 {
+		return model.Event{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return events[0], nil
+}
 
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0777)
+// List returns the events lit on any night of the month of date. A schedule is stored in the file of the month
+// it was published for, but may list nights in the months either side, e.g. "Tuesday, December 31 through
+// Wednesday, January 1" on the December schedule, so those files are searched too.
+func (f *FileStore) List(date time.Time) ([]model.Event, error) {
+	month := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	events, found, err := f.collect(month, month, func(event model.Event) bool {
+		return isLitInMonth(event, month)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return []model.Event{}, err
 	}
-	defer file.Close()
-	encoder := json.NewEncoder(file)
-	if err = encoder.Encode(events); err != nil {
-		return fmt.Errorf("failed to encode json: %w", err)
+	if len(found) == 0 {
+		return []model.Event{}, &os.PathError{Op: "open", Path: generateFilename(month, f.path), Err: os.ErrNotExist}
 	}
-	return nil
+	return events, nil
+}
+
+// ListRange returns the events lit on any night from one date through another, in the order they are stored.
+func (f *FileStore) ListRange(from, to time.Time) ([]model.Event, error) {
+	first := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	events, _, err := f.collect(first, last, func(event model.Event) bool {
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			if IsLitOn(event, day) {
+				return true
+			}
+		}
+		return false
+	})
+	return events, err
+}
+
+// collect returns the kept events of every month file from the month before from through the month after to,
+// listing an event once when the schedules of two months both list it. It also returns the months that had
+// a file.
+func (f *FileStore) collect(from, to time.Time, keep func(model.Event) bool) ([]model.Event, map[time.Time]bool, error) {
+	events := []model.Event{}
+	found := make(map[time.Time]bool)
+	seen := make(map[string]bool)
+	first := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	last := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		monthEvents, err := readEventsFromFile(m, f.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return []model.Event{}, found, err
+		}
+		found[m] = true
+		for _, event := range monthEvents {
+			if seen[event.Key()] || !keep(event) {
+				continue
+			}
+			seen[event.Key()] = true
+			events = append(events, event)
+		}
+	}
+	return events, found, nil
+}
+
+// readEventsFromFile returns the events of the month file of date, upgrading it from an older schema version.
+func readEventsFromFile(date time.Time, path string) ([]model.Event, error) {
+	file, _, err := readMonthFile(date, path)
+	if err != nil {
+		return nil, err
+	}
+	return file.Events, nil
+}
+
+// writeEventsToFile writes a new month file of date for events with an unknown source.
+func writeEventsToFile(date time.Time, path string, events []model.Event) error {
+	return writeMonthFile(date, path, MonthFile{Events: events}, true)
 }
 
 func generateFilename(date time.Time, path string) string {
@@ -121,9 +282,75 @@ func isSameDate(t1, t2 time.Time) bool {
 	return t1.Year() == t2.Year() && t1.Month() == t2.Month() && t1.Day() == t2.Day()
 }
 
+// isLitInMonth reports whether any night of the event falls in the month of date.
+func isLitInMonth(event model.Event, date time.Time) bool {
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		if IsLitOn(event, day) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLitOn reports whether any night of the event falls on date.
+// Events stored before nights were tracked only carry a start and, at best, an end timestamp.
+func IsLitOn(event model.Event, date time.Time) bool {
+	if len(event.Nights) > 0 {
+		for _, night := range event.Nights {
+			if isSameDate(night, date) {
+				return true
+			}
+		}
+		return false
+	}
+	if isSameDate(event.StartTimeStamp, date) {
+		return true
+	}
+	if event.EndTimeStamp.IsZero() {
+		return false
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, event.StartTimeStamp.Location())
+	return !day.Before(event.StartTimeStamp) && !day.After(event.EndTimeStamp)
+}
+
+// HasMonth reports whether the current month's file exists.
+func (f *FileStore) HasMonth() (bool, error) {
+	return f.CheckFileExists()
+}
+
+// Months returns the months that have a file, oldest first.
+func (f *FileStore) Months() ([]time.Time, error) {
+	return monthsIn(f.path)
+}
+
+// monthsIn returns the months that have a file in dir, oldest first. A missing dir has none.
+func monthsIn(dir string) ([]time.Time, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []time.Time{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	months := []time.Time{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		month, err := time.Parse(time.DateOnly, name)
+		if err != nil || month.Day() != 1 {
+			continue
+		}
+		months = append(months, month)
+	}
+	return months, nil
+}
+
 func (f *FileStore) CheckFileExists() (bool, error) {
 	filename := generateFilename(f.today, f.path)
-	if _, err := os.OpenFile(filename, os.O_RDONLY, 0777); err != nil {
+	if _, err := os.Stat(filename); err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
 		} else {
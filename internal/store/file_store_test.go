@@ -1,7 +1,6 @@
 package store
 
 import (
-	"fmt"
 	"io"
 	"os"
 	"reflect"
@@ -13,113 +12,267 @@ import (
 )
 
 func TestFileStore_Create(t *testing.T) {
-	type args struct {
-		date   time.Time
-		events []model.Event
-	}
 	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
-		err     error
+		name     string
+		event    model.Event
+		wantKeys []string
+		wantErr  error
 	}{
 		{
-			name: "unimplemented",
-			args: args{
-				events: []model.Event{},
-			},
-			wantErr: true,
-			err:     fmt.Errorf("unimplemented"),
+			name:     "adds an event in date order",
+			event:    model.Event{StartTimeStamp: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), Color: "orange"},
+			wantKeys: []string{"2024-11-01", "2024-11-05", "2024-11-06"},
+		},
+		{
+			name:     "fails when the month lists the event",
+			event:    model.Event{StartTimeStamp: time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC), Color: "purple"},
+			wantKeys: []string{"2024-11-05", "2024-11-06"},
+			wantErr:  ErrExists,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			f := &FileStore{}
-			if err := f.Create(tt.args.events); (err != nil) != tt.wantErr {
-				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			f := &FileStore{path: t.TempDir(), today: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)}
+			require.NoError(t, f.CreateMonth(model.Source{}, novemberEvents()))
+
+			err := f.Create(tt.event)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
 			}
+			got, err := f.ReadMonth()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantKeys, eventKeys(got))
 		})
 	}
 }
 
 func TestFileStore_Delete(t *testing.T) {
-	type args struct {
-		event model.Event
-	}
 	tests := []struct {
 		name    string
-		args    args
-		wantErr bool
-		err     error
+		event   model.Event
+		want    []string
+		wantErr string
 	}{
 		{
-			name: "unimplemented",
-			args: args{
-				event: model.Event{},
-			},
-			wantErr: true,
-			err:     fmt.Errorf("unimplemented"),
+			name:  "removes the event with the same key",
+			event: model.Event{StartTimeStamp: time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC)},
+			want:  []string{"2024-11-06"},
+		},
+		{
+			name:    "unknown event",
+			event:   model.Event{StartTimeStamp: time.Date(2024, 11, 7, 0, 0, 0, 0, time.UTC)},
+			want:    []string{"2024-11-05", "2024-11-06"},
+			wantErr: "event not found: 2024-11-07",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			f := &FileStore{}
-			if err := f.Delete(tt.args.event); (err != nil) != tt.wantErr {
-				t.Errorf("Delete() error = %v, wantErr %v", err, tt.wantErr)
+			f := &FileStore{path: t.TempDir(), today: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)}
+			require.NoError(t, f.CreateMonth(model.Source{}, novemberEvents()))
+
+			err := f.Delete(tt.event)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
 			}
+			got, err := f.ReadMonth()
+			require.NoError(t, err)
+			require.Equal(t, tt.want, eventKeys(got))
 		})
 	}
 }
 
+func novemberEvents() []model.Event {
+	return []model.Event{
+		{
+			DateString:     "Tuesday, November 5, 2024",
+			StartTimeStamp: time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC),
+			Color:          "red/white/blue",
+			Description:    "in recognition of Election Day 2024",
+		},
+		{
+			DateString:     "Wednesday, November 6, 2024",
+			StartTimeStamp: time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC),
+			Color:          "teal",
+			Description:    "in recognition of the Alzheimer Foundations’ annual “Light the World Teal” Campaign",
+		},
+	}
+}
+
+func eventKeys(events []model.Event) []string {
+	keys := []string{}
+	for _, event := range events {
+		keys = append(keys, event.Key())
+	}
+	return keys
+}
+
 func TestFileStore_List(t *testing.T) {
 	type args struct {
 		date time.Time
 	}
 	tests := []struct {
-		name    string
-		args    args
-		want    []model.Event
-		wantErr bool
+		name            string
+		args            args
+		wantDateStrings []string
+		wantErr         bool
 	}{
-		// TODO: Add test cases.
+		{
+			name: "lists the events lit in the month",
+			args: args{
+				date: time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC),
+			},
+			wantDateStrings: []string{
+				"Monday, November 25, 2024",
+				"Saturday, November 30 through Sunday, December 1",
+			},
+		},
+		{
+			name: "lists a range from the previous month once when both schedules list it",
+			args: args{
+				date: time.Date(2024, 12, 5, 0, 0, 0, 0, time.UTC),
+			},
+			wantDateStrings: []string{
+				"Saturday, November 30 through Sunday, December 1",
+				"Tuesday, December 31 through Wednesday, January 1",
+			},
+		},
+		{
+			name: "lists a range from the previous year without a file for the month",
+			args: args{
+				date: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			wantDateStrings: []string{
+				"Tuesday, December 31 through Wednesday, January 1",
+			},
+		},
+		{
+			name: "fails when no file may list the month",
+			args: args{
+				date: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			f := &FileStore{}
+			f := &FileStore{
+				path: "file-test-fixtures/cross-month-cases",
+			}
 			got, err := f.List(tt.args.date)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("List() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("List() got = %v, want %v", got, tt.want)
+			gotDateStrings := []string{}
+			for _, event := range got {
+				gotDateStrings = append(gotDateStrings, event.DateString)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(gotDateStrings, tt.wantDateStrings) {
+				t.Errorf("List() got = %v, want %v", gotDateStrings, tt.wantDateStrings)
 			}
 		})
 	}
 }
 
+func TestFileStore_ListRange_sameNight(t *testing.T) {
+	night := time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC)
+	events := []model.Event{
+		{DateString: "Wednesday, November 20, 2024", StartTimeStamp: night, Color: "pink/blue/white", Description: "Tonight City Hall will be pink, blue and white in recognition of Transgender Day of Remembrance"},
+		{DateString: "Wednesday, November 20, 2024", StartTimeStamp: night, Seq: 1, Color: "pink/blue/white", Description: "Tonight City Hall will be pink, blue and white in recognition of Transgender Awareness Week"},
+	}
+	f := &FileStore{path: t.TempDir(), today: night}
+	require.NoError(t, writeEventsToFile(night, f.path, events))
+
+	got, err := f.ListRange(night, night)
+	require.NoError(t, err)
+	require.Equal(t, events, got)
+}
+
 func TestFileStore_Read(t *testing.T) {
 	type args struct {
+		path string
 		date time.Time
 	}
 	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
+		name           string
+		args           args
+		wantDateString string
+		wantErr        bool
+		errString      string
 	}{
-		// TODO: Add test cases.
+		{
+			name: "finds event on the first night of an 'and' listing",
+			args: args{
+				date: time.Date(2024, 11, 3, 20, 0, 0, 0, time.UTC),
+			},
+			wantDateString: "Sunday, November 3 and Monday, November 4, 2024",
+		},
+		{
+			name: "finds event on the second night of an 'and' listing",
+			args: args{
+				date: time.Date(2024, 11, 4, 20, 0, 0, 0, time.UTC),
+			},
+			wantDateString: "Sunday, November 3 and Monday, November 4, 2024",
+		},
+		{
+			name: "finds event on the last night of a 'through' listing",
+			args: args{
+				date: time.Date(2024, 11, 29, 20, 0, 0, 0, time.UTC),
+			},
+			wantDateString: "Thursday, November 28 through Friday, November 29, 2024",
+		},
+		{
+			name: "finds a range listed on the previous month's schedule",
+			args: args{
+				path: "file-test-fixtures/cross-month-cases",
+				date: time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC),
+			},
+			wantDateString: "Tuesday, December 31 through Wednesday, January 1",
+		},
+		{
+			name: "fails when no event is lit on the date",
+			args: args{
+				date: time.Date(2024, 11, 5, 20, 0, 0, 0, time.UTC),
+			},
+			wantErr:   true,
+			errString: "event not found",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			f := &FileStore{}
-			if _, err := f.Read(tt.args.date); (err != nil) != tt.wantErr {
+			path := tt.args.path
+			if path == "" {
+				path = "file-test-fixtures/multi-night-cases"
+			}
+			f := &FileStore{
+				path:  path,
+				today: tt.args.date,
+			}
+			got, err := f.Read(tt.args.date)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("Read() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				require.Contains(t, err.Error(), tt.errString)
+				return
 			}
+			require.Equal(t, tt.wantDateString, got.DateString)
 		})
 	}
 }
 
 func TestFileStore_readEventsFromFile(t *testing.T) {
+	// the success fixture is named for its first event, so copy it to where the store looks for the month
+	success := t.TempDir()
+	data, err := os.ReadFile("file-test-fixtures/success-cases/2024-11-05.json")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(generateFilename(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), success), data, 0644))
+
 	type args struct {
 		date time.Time
 		path string
@@ -135,7 +288,7 @@ func TestFileStore_readEventsFromFile(t *testing.T) {
 			name: "reading multiple valid events from correctly formated file succeeds",
 			args: args{
 				date: time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC),
-				path: "file-test-fixtures/success-cases",
+				path: success,
 			},
 			want: []model.Event{
 				{
@@ -274,35 +427,48 @@ func TestFileStore_writeEventsToFile(t *testing.T) {
 }
 
 func TestFileStore_Update(t *testing.T) {
-	type args struct {
-		event model.Event
-	}
 	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
-		err     error
+		name      string
+		event     model.Event
+		wantKeys  []string
+		wantColor map[string]string
 	}{
 		{
-			name: "unimplemented",
-			args: args{
-				event: model.Event{},
+			name: "replaces the event with the same key",
+			event: model.Event{
+				StartTimeStamp: time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC),
+				Color:          "purple",
 			},
-			wantErr: true,
-			err:     fmt.Errorf("unimplemented"),
+			wantKeys:  []string{"2024-11-05", "2024-11-06"},
+			wantColor: map[string]string{"2024-11-05": "red/white/blue", "2024-11-06": "purple"},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			f := &FileStore{}
-			if err := f.Update(tt.args.event); (err != nil) != tt.wantErr {
-				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
-				require.EqualError(t, err, tt.err.Error())
+			f := &FileStore{path: t.TempDir(), today: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)}
+			require.NoError(t, f.CreateMonth(model.Source{}, novemberEvents()))
+
+			require.NoError(t, f.Update(tt.event))
+			got, err := f.ReadMonth()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantKeys, eventKeys(got))
+			for _, event := range got {
+				require.Equal(t, tt.wantColor[event.Key()], event.Color)
 			}
 		})
 	}
 }
 
+func TestFileStore_UpdateMissing(t *testing.T) {
+	f := &FileStore{path: t.TempDir(), today: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)}
+	err := f.Update(model.Event{StartTimeStamp: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, f.CreateMonth(model.Source{}, novemberEvents()))
+	err = f.Update(model.Event{StartTimeStamp: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)})
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
 func TestNewFileStore(t *testing.T) {
 	tests := []struct {
 		name string
@@ -330,12 +496,12 @@ func Test_generateFilename(t *testing.T) {
 		want string
 	}{
 		{
-			name: "generates filename from date and path",
+			name: "generates filename from the first of the date's month and path",
 			args: args{
 				date: time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC),
 				path: "./file-test-fixtures",
 			},
-			want: "./file-test-fixtures/2024-11-05.json",
+			want: "./file-test-fixtures/2024-11-01.json",
 		},
 	}
 	for _, tt := range tests {
@@ -437,6 +603,79 @@ func Test_isSameDate(t *testing.T) {
 	}
 }
 
+func Test_isLitOn(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	type args struct {
+		event model.Event
+		date  time.Time
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "matches any listed night",
+			args: args{
+				event: model.Event{
+					StartTimeStamp: time.Date(2024, 11, 1, 0, 0, 0, 0, loc),
+					EndTimeStamp:   time.Date(2024, 11, 5, 0, 0, 0, 0, loc),
+					Nights: []time.Time{
+						time.Date(2024, 11, 1, 0, 0, 0, 0, loc),
+						time.Date(2024, 11, 5, 0, 0, 0, 0, loc),
+					},
+				},
+				date: time.Date(2024, 11, 5, 0, 0, 0, 0, loc),
+			},
+			want: true,
+		},
+		{
+			name: "does not match an unlisted date between listed nights",
+			args: args{
+				event: model.Event{
+					StartTimeStamp: time.Date(2024, 11, 1, 0, 0, 0, 0, loc),
+					EndTimeStamp:   time.Date(2024, 11, 5, 0, 0, 0, 0, loc),
+					Nights: []time.Time{
+						time.Date(2024, 11, 1, 0, 0, 0, 0, loc),
+						time.Date(2024, 11, 5, 0, 0, 0, 0, loc),
+					},
+				},
+				date: time.Date(2024, 11, 3, 0, 0, 0, 0, loc),
+			},
+			want: false,
+		},
+		{
+			name: "falls back to the start and end span when nights are missing",
+			args: args{
+				event: model.Event{
+					StartTimeStamp: time.Date(2024, 11, 28, 0, 0, 0, 0, loc),
+					EndTimeStamp:   time.Date(2024, 11, 30, 0, 0, 0, 0, loc),
+				},
+				date: time.Date(2024, 11, 29, 0, 0, 0, 0, loc),
+			},
+			want: true,
+		},
+		{
+			name: "falls back to the start date when nights and end are missing",
+			args: args{
+				event: model.Event{
+					StartTimeStamp: time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC),
+				},
+				date: time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC),
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsLitOn(tt.args.event, tt.args.date); got != tt.want {
+				t.Errorf("IsLitOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoadImageFromFile(t *testing.T) {
 	type args struct {
 		path string
@@ -506,6 +745,7 @@ func Test_readImageMetadataFromFile(t *testing.T) {
 			want: []model.ImageMetadata{
 				{
 					FileName: "blue-pink-white.jpg",
+					Colors:   []string{"blue", "pink", "white"},
 					AltText:  "San Francisco City Hall illuminated at night with vibrant pink, blue, and white lights highlighting the dome and façade. The structure stands out against a dark black sky, showcasing its detailed architectural elements and grandeur.",
 					Attribution: model.Attribution{
 						Creator:    "Kae Ng",
@@ -516,6 +756,7 @@ func Test_readImageMetadataFromFile(t *testing.T) {
 				},
 				{
 					FileName: "orange.jpg",
+					Colors:   []string{"orange"},
 					AltText:  "San Francisco City Hall illuminated in warm orange lights at night, highlighting the dome and front façade. A pathway lined with trees leads to the building, where a single person is seen walking towards the entrance. The surrounding sky is dark, emphasizing the vibrant glow of the building’s architectural details.",
 					Attribution: model.Attribution{
 						Creator:    "Gurpreet Singh",
@@ -526,14 +767,26 @@ func Test_readImageMetadataFromFile(t *testing.T) {
 				},
 				{
 					FileName: "purple.jpg",
-					AltText:  "",
+					Colors:   []string{"purple"},
+					AltText:  "San Francisco City Hall illuminated in vibrant purple lights under a dramatic cloudy night sky. The building’s grand dome and façade are highlighted by the lighting, while large glowing white sculptures resembling rabbits are displayed in the foreground, surrounded by a crowd of onlookers.",
 					Attribution: model.Attribution{
 						Creator:    "Brittany Murphy/The Chronicle",
-						Title:      "San Francisco City Hall illuminated in vibrant purple lights under a dramatic cloudy night sky. The building’s grand dome and façade are highlighted by the lighting, while large glowing white sculptures resembling rabbits are displayed in the foreground, surrounded by a crowd of onlookers.",
+						Title:      "",
 						SourceURL:  "https://s.hdnux.com/photos/45/53/35/9877325/12/960x0.webp",
 						LicenseURL: "",
 					},
 				},
+				{
+					FileName: "shades-of-amber.jpg",
+					Colors:   []string{"amber"},
+					AltText:  "San Francisco City Hall illuminated in warm amber and orange lighting, highlighting its architectural details. The structure features tall columns, intricate designs, and a prominent dome at the center. Trees and lamp posts frame the scene, adding depth to the composition.",
+					Attribution: model.Attribution{
+						Creator:    "Michelle Gachet/The Chronicle",
+						Title:      "",
+						SourceURL:  "https://www.sfgate.com/living/slideshow/San-Francisco-City-Hall-colors-121255.php",
+						LicenseURL: "",
+					},
+				},
 			},
 			wantErr: false,
 		},
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"city-hall-lights/internal/model"
+)
+
+// gitIgnore keeps the lock file, the temporary files of interrupted writes and the log of scrape runs, which
+// is written on every run, out of the repository.
+const gitIgnore = ".lock\n.*.tmp-*\n/" + runsDir + "/\n"
+
+// Committer is implemented by backends that publish their writes as a unit, such as GitStore. StoreSchedule,
+// Reconcile and Override commit once they have written everything a change touches.
+type Committer interface {
+	Commit(message string) error
+}
+
+// commit commits the writes made through b, when b is a Committer.
+func commit(b Backend, message string) error {
+	committer, ok := b.(Committer)
+	if !ok {
+		return nil
+	}
+	return committer.Commit(message)
+}
+
+// GitStore keeps the JSON month files in a git repository and commits each change to the schedule, so that the
+// repository doubles as a readable history of City Hall lighting that can be published as open data. It shells
+// out to git, which must be installed.
+type GitStore struct {
+	*FileStore
+	// remote is pushed to after every commit when set, as a remote name or URL.
+	remote string
+}
+
+// OpenGitStore opens the git repository at path, initializing it when path isn't one yet. Commits are pushed
+// to remote unless it is empty.
+func OpenGitStore(path, remote string) (*GitStore, error) {
+	fs := NewFileStore().In(path)
+	g := &GitStore{FileStore: &fs, remote: remote}
+	if err := os.MkdirAll(path, dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, ".git")); errors.Is(err, os.ErrNotExist) {
+		if _, err = g.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+	}
+	if err := ignore(filepath.Join(path, ".gitignore")); err != nil {
+		return nil, err
+	}
+	// commits need an author; fall back to the bot's own when git has none configured
+	if _, err := g.git("config", "user.email"); err != nil {
+		if _, err = g.git("config", "user.name", "city-hall-lights"); err != nil {
+			return nil, err
+		}
+		if _, err = g.git("config", "user.email", "city-hall-lights@users.noreply.github.com"); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// ignore adds the patterns of gitIgnore missing from the .gitignore file at filename, creating it if needed, so
+// that repositories initialized by older versions pick up new patterns.
+func ignore(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	existing := strings.Split(string(content), "\n")
+	missing := ""
+	for _, pattern := range strings.SplitAfter(gitIgnore, "\n") {
+		if pattern != "" && !slices.Contains(existing, strings.TrimSuffix(pattern, "\n")) {
+			missing += pattern
+		}
+	}
+	if missing == "" {
+		return nil
+	}
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		missing = "\n" + missing
+	}
+	return os.WriteFile(filename, append(content, missing...), filePerm)
+}
+
+// ForMonth returns a view of the store scoped to the schedule of month, committing to the same repository.
+func (g *GitStore) ForMonth(month time.Time) Backend {
+	monthStore := g.At(month)
+	return &GitStore{FileStore: &monthStore, remote: g.remote}
+}
+
+// RecordPost records a published post in the ledger and commits it.
+func (g *GitStore) RecordPost(post model.Post) error {
+	if err := g.FileStore.RecordPost(post); err != nil {
+		return err
+	}
+	return g.Commit(commitMessage(post.Night, "posted %s", post.Night.Format(time.DateOnly)))
+}
+
+// Commit commits every file written since the last commit, if any, and pushes the commit to the remote.
+// A failed push is reported but not returned, as the commit is kept and goes out with the next push.
+func (g *GitStore) Commit(message string) error {
+	unlock, err := g.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err = g.git("add", "--all"); err != nil {
+		return err
+	}
+	if _, err = g.git("diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+	if _, err = g.git("commit", "--quiet", "--message", message); err != nil {
+		return err
+	}
+	if g.remote == "" {
+		return nil
+	}
+	if _, err = g.git("push", "--quiet", g.remote, "HEAD"); err != nil {
+		fmt.Println("failed to push store: ", err)
+	}
+	return nil
+}
+
+func (g *GitStore) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.path
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// commitMessage prefixes a commit message with the month of the schedule it changes, e.g. "Nov 2024: ".
+func commitMessage(month time.Time, format string, args ...any) string {
+	return month.Format("Jan 2006") + ": " + fmt.Sprintf(format, args...)
+}
+
+// changeSummary describes changes to the schedule of month as a commit message, e.g.
+// "Nov 2024: 2 added, 1 recolored".
+func changeSummary(month time.Time, changes []model.Change) string {
+	counts := make(map[model.ChangeKind]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+	parts := []string{}
+	for _, kind := range []model.ChangeKind{model.ChangeAdded, model.ChangeRemoved, model.ChangeRecolored, model.ChangeRedescribed, model.ChangeRescheduled} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf(`%d %s`, counts[kind], kind))
+		}
+	}
+	return commitMessage(month, "%s", strings.Join(parts, ", "))
+}
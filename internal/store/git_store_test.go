@@ -0,0 +1,128 @@
+package store
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func openTestGitStore(t *testing.T) (*GitStore, string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "lights.git")
+	gitOutput(t, t.TempDir(), "init", "--quiet", "--bare", remote)
+	g, err := OpenGitStore(filepath.Join(t.TempDir(), "events"), remote)
+	require.NoError(t, err)
+	return g, remote
+}
+
+func TestGitStore(t *testing.T) {
+	g, remote := openTestGitStore(t)
+	november := g.ForMonth(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC))
+	source := model.Source{ScrapedAt: time.Date(2024, 11, 1, 8, 0, 0, 0, time.UTC), Hash: "abc123"}
+
+	require.NoError(t, november.RecordScrapeRun(model.ScrapeRun{FetchedAt: source.ScrapedAt}))
+	require.NoError(t, november.Quarantine([]model.UnparsedLine{{RawEventString: "TBD – teal", Stage: "date"}}))
+	require.NoError(t, StoreSchedule(november, source, novemberEvents()))
+
+	scraped := novemberEvents()
+	scraped[1].Color = "purple"
+	scraped = append(scraped, model.Event{StartTimeStamp: time.Date(2024, 11, 9, 0, 0, 0, 0, time.UTC), Color: "orange"})
+	_, err := Reconcile(november, source, scraped)
+	require.NoError(t, err)
+	// an unchanged schedule makes no commit
+	_, err = Reconcile(november, source, scraped)
+	require.NoError(t, err)
+
+	night := time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, november.RecordPost(model.Post{EventKey: "2024-11-06", Night: night, URI: "at://did:plc:abc/app.bsky.feed.post/1"}))
+
+	log := gitOutput(t, remote, "log", "--format=%s")
+	require.Equal(t, []string{
+		"Nov 2024: posted 2024-11-06",
+		"Nov 2024: 1 added, 1 recolored",
+		"Nov 2024: 2 events published",
+	}, strings.Split(log, "\n"))
+
+	files := gitOutput(t, remote, "ls-tree", "-r", "--name-only", "HEAD")
+	require.Equal(t, []string{
+		".gitignore",
+		"2024-11-01.json",
+		"changes/2024-11-01.json",
+		"ledger/2024-11-01.json",
+		"quarantine/2024-11-01.json",
+		"revisions/2024-11-01.json",
+	}, strings.Split(files, "\n"))
+}
+
+func TestGitStore_updatesIgnore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	path := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(path, ".gitignore"), []byte(".lock\n.*.tmp-*"), filePerm))
+	_, err := OpenGitStore(path, "")
+	require.NoError(t, err)
+	_, err = OpenGitStore(path, "")
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(path, ".gitignore"))
+	require.NoError(t, err)
+	require.Equal(t, gitIgnore, string(content))
+}
+
+func TestGitStore_reopen(t *testing.T) {
+	g, _ := openTestGitStore(t)
+	november := g.ForMonth(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, StoreSchedule(november, model.Source{}, novemberEvents()))
+
+	reopened, err := OpenGitStore(g.path, "")
+	require.NoError(t, err)
+	events, err := reopened.ForMonth(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)).ReadMonth()
+	require.NoError(t, err)
+	require.Equal(t, eventKeys(novemberEvents()), eventKeys(events))
+	require.Equal(t, "1", gitOutput(t, g.path, "rev-list", "--count", "HEAD"))
+}
+
+func TestChangeSummary(t *testing.T) {
+	november := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		changes []model.ChangeKind
+		want    string
+	}{
+		{
+			name:    "added and recolored",
+			changes: []model.ChangeKind{model.ChangeRecolored, model.ChangeAdded, model.ChangeAdded},
+			want:    "Nov 2024: 2 added, 1 recolored",
+		},
+		{
+			name:    "every kind",
+			changes: []model.ChangeKind{model.ChangeRescheduled, model.ChangeRedescribed, model.ChangeRecolored, model.ChangeRemoved, model.ChangeAdded},
+			want:    "Nov 2024: 1 added, 1 removed, 1 recolored, 1 redescribed, 1 rescheduled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := []model.Change{}
+			for _, kind := range tt.changes {
+				changes = append(changes, model.Change{Kind: kind})
+			}
+			require.Equal(t, tt.want, changeSummary(november, changes))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"city-hall-lights/internal/model"
+)
+
+// ImportFiles copies the JSON month files of a FileStore into another backend: each month's events, its
+// quarantined lines, change log and revision log, or a baseline revision of its events when it has none, the posts in the ledger and the scrape runs. Months the backend already
+// has are skipped; ledger entries are upserted, so an import can be repeated. It returns the months imported.
+func ImportFiles(from *FileStore, to Backend) ([]time.Time, error) {
+	months, err := from.Months()
+	if err != nil {
+		return nil, err
+	}
+	imported := []time.Time{}
+	for _, month := range months {
+		source := from.At(month)
+		target := to.ForMonth(month)
+		exists, err := target.HasMonth()
+		if err != nil {
+			return imported, err
+		}
+		if exists {
+			continue
+		}
+		events, err := source.ReadMonth()
+		if err != nil {
+			return imported, fmt.Errorf("%s: %w", month.Format(time.DateOnly), err)
+		}
+		origin, err := source.MonthSource()
+		if err != nil {
+			return imported, fmt.Errorf("%s: %w", month.Format(time.DateOnly), err)
+		}
+		if err = target.CreateMonth(origin, events); err != nil {
+			return imported, err
+		}
+		if lines, err := source.ListQuarantine(month); err == nil {
+			if err = target.Quarantine(lines); err != nil {
+				return imported, err
+			}
+		}
+		if changes, err := source.ListChanges(month); err == nil {
+			if err = target.LogChanges(changes); err != nil {
+				return imported, err
+			}
+		}
+		revisions, err := source.ListRevisions(month)
+		if os.IsNotExist(err) {
+			revisions, err = revise(events, model.RevisionMigration, origin), nil
+		}
+		if err != nil {
+			return imported, err
+		}
+		if err = target.RecordRevisions(revisions); err != nil {
+			return imported, err
+		}
+		imported = append(imported, month)
+	}
+
+	ledgerMonths, err := monthsIn(filepath.Join(from.path, ledgerDir))
+	if err != nil {
+		return imported, err
+	}
+	for _, month := range ledgerMonths {
+		posts, err := from.ListPosts(month)
+		if err != nil {
+			return imported, err
+		}
+		for _, post := range posts {
+			if err = to.RecordPost(post); err != nil {
+				return imported, err
+			}
+		}
+	}
+
+	runMonths, err := monthsIn(filepath.Join(from.path, runsDir))
+	if err != nil {
+		return imported, err
+	}
+	for _, month := range runMonths {
+		stored, err := to.ListScrapeRuns(month)
+		if err == nil && len(stored) > 0 {
+			continue
+		}
+		runs, err := from.ListScrapeRuns(month)
+		if err != nil {
+			return imported, err
+		}
+		for _, run := range runs {
+			if err = to.RecordScrapeRun(run); err != nil {
+				return imported, err
+			}
+		}
+	}
+	return imported, commit(to, fmt.Sprintf(`imported %d months from %s`, len(imported), from.path))
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"city-hall-lights/internal/model"
+)
+
+// ledgerDir holds the posts published for nights of each month. It is checked before posting, so that a
+// retried or doubled run doesn't post an event twice, and lets a later change to an event be followed up on
+// the post that announced it.
+const ledgerDir = "ledger"
+
+// Ledger records every published post.
+type Ledger interface {
+	RecordPost(post model.Post) error
+	ListPosts(date time.Time) ([]model.Post, error)
+	PostedOn(night time.Time) ([]model.Post, error)
+}
+
+var _ Ledger = (*FileStore)(nil)
+
+// RecordPost stores a published post in the ledger file of the month of its night, replacing an earlier record
+// of the same post.
+func (f *FileStore) RecordPost(post model.Post) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	posts, err := f.ListPosts(post.Night)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	replaced := false
+	for i := range posts {
+		if posts[i].URI == post.URI {
+			posts[i] = post
+			replaced = true
+		}
+	}
+	if !replaced {
+		posts = append(posts, post)
+	}
+
+	return writeJSONAtomic(generateFilename(post.Night, filepath.Join(f.path, ledgerDir)), posts, false)
+}
+
+// ListPosts returns the posts published for nights in the month of date, including deleted ones.
+func (f *FileStore) ListPosts(date time.Time) ([]model.Post, error) {
+	filename := generateFilename(date, filepath.Join(f.path, ledgerDir))
+	if err := validateFilename(filename); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var posts []model.Post
+	decoder := json.NewDecoder(file)
+	if err = decoder.Decode(&posts); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+	return posts, nil
+}
+
+// PostedOn returns the live posts published for the night of date.
+func (f *FileStore) PostedOn(date time.Time) ([]model.Post, error) {
+	posts, err := f.ListPosts(date)
+	if os.IsNotExist(err) {
+		return []model.Post{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	live := []model.Post{}
+	for _, post := range posts {
+		if !post.Deleted && isSameDate(post.Night, date) {
+			live = append(live, post)
+		}
+	}
+	return live, nil
+}
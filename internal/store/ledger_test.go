@@ -0,0 +1,36 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_RecordPost(t *testing.T) {
+	f := &FileStore{path: t.TempDir()}
+	night := time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC)
+
+	posted, err := f.PostedOn(night)
+	require.NoError(t, err)
+	require.Empty(t, posted)
+
+	post := model.Post{EventKey: "2024-11-06", Night: night, URI: "at://did:plc:abc/app.bsky.feed.post/1", CID: "bafy1"}
+	require.NoError(t, f.RecordPost(post))
+	require.NoError(t, f.RecordPost(model.Post{EventKey: "2024-11-07", Night: night.AddDate(0, 0, 1), URI: "at://did:plc:abc/app.bsky.feed.post/2"}))
+
+	posted, err = f.PostedOn(night)
+	require.NoError(t, err)
+	require.Equal(t, []model.Post{post}, posted)
+
+	post.Deleted = true
+	require.NoError(t, f.RecordPost(post))
+	posted, err = f.PostedOn(night)
+	require.NoError(t, err)
+	require.Empty(t, posted)
+
+	all, err := f.ListPosts(night)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+}
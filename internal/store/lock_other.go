@@ -0,0 +1,32 @@
+//go:build !unix
+
+package store
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockTimeout bounds how long to wait for the lock file to be removed, so that one left behind by a crashed
+// run doesn't block every later one.
+const lockTimeout = 30 * time.Second
+
+// lockFile holds the lock by creating the lock file exclusively, for platforms without flock.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, filePerm)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to open lock file: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock store: %s held for over %s", path, lockTimeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"city-hall-lights/internal/model"
+)
+
+// quarantineDir holds the lines of each month's schedule that could not be parsed, kept apart from the
+// month's events so that an operator can review them.
+const quarantineDir = "quarantine"
+
+// Quarantine stores the unparsed lines of the current month's schedule, replacing any from an earlier scrape.
+// A schedule without unparsed lines clears them.
+func (f *FileStore) Quarantine(lines []model.UnparsedLine) error {
+	if lines == nil {
+		lines = []model.UnparsedLine{}
+	}
+	filename := generateFilename(f.today, filepath.Join(f.path, quarantineDir))
+	if err := validateFilename(filename); err != nil {
+		return err
+	}
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return writeJSONAtomic(filename, lines, false)
+}
+
+// ListQuarantine returns the unparsed lines stored for the month of date.
+func (f *FileStore) ListQuarantine(date time.Time) ([]model.UnparsedLine, error) {
+	filename := generateFilename(date, filepath.Join(f.path, quarantineDir))
+	if err := validateFilename(filename); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var lines []model.UnparsedLine
+	decoder := json.NewDecoder(file)
+	if err = decoder.Decode(&lines); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+	return lines, nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_Quarantine(t *testing.T) {
+	today := time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		writes [][]model.UnparsedLine
+		want   []model.UnparsedLine
+	}{
+		{
+			name: "stores unparsed lines for the month",
+			writes: [][]model.UnparsedLine{
+				{
+					{
+						RawEventString: "Tuesday, November 19, 2024 red/white in recognition of the National Day of Monaco",
+						Stage:          "split",
+						Offending:      "Tuesday, November 19, 2024 red/white in recognition of the National Day of Monaco",
+						Suggestion:     `separate the fields as "<date> – <color> – <description>"`,
+						Error:          "expected 3 parts, found 1",
+					},
+				},
+			},
+			want: []model.UnparsedLine{
+				{
+					RawEventString: "Tuesday, November 19, 2024 red/white in recognition of the National Day of Monaco",
+					Stage:          "split",
+					Offending:      "Tuesday, November 19, 2024 red/white in recognition of the National Day of Monaco",
+					Suggestion:     `separate the fields as "<date> – <color> – <description>"`,
+					Error:          "expected 3 parts, found 1",
+				},
+			},
+		},
+		{
+			name: "a later scrape replaces earlier unparsed lines",
+			writes: [][]model.UnparsedLine{
+				{{RawEventString: "first", Stage: "split"}},
+				{{RawEventString: "second", Stage: "date"}},
+			},
+			want: []model.UnparsedLine{{RawEventString: "second", Stage: "date"}},
+		},
+		{
+			name: "a scrape without unparsed lines clears earlier ones",
+			writes: [][]model.UnparsedLine{
+				{{RawEventString: "first", Stage: "split"}},
+				nil,
+			},
+			want: []model.UnparsedLine{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &FileStore{
+				path:  t.TempDir(),
+				today: today,
+			}
+			for _, lines := range tt.writes {
+				require.NoError(t, f.Quarantine(lines))
+			}
+			got, err := f.ListQuarantine(today)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+
+			events, err := f.List(today)
+			require.Error(t, err, "quarantined lines must not be written to the month's events")
+			require.Empty(t, events)
+		})
+	}
+}
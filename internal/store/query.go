@@ -0,0 +1,179 @@
+package store
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"city-hall-lights/internal/model"
+)
+
+// PostStatus selects events by whether they have been posted.
+type PostStatus int
+
+const (
+	AnyPostStatus PostStatus = iota
+	Posted
+	Unposted
+)
+
+// nextHorizon is how far ahead Next looks for an upcoming event. Schedules are published a month at a time,
+// so anything further out is not yet known.
+const nextHorizon = 12
+
+// Query selects the events lit on any night from From through To. The zero value of each other field matches
+// every event.
+type Query struct {
+	From time.Time
+	To   time.Time
+	// Colors matches an event lit in any of the colors, by palette name or as written on the schedule.
+	Colors  []string
+	Keyword string
+	Purpose model.PurposeKind
+	Posted  PostStatus
+}
+
+// Find returns the events matching q, in the order the store lists them. The ledger is only read when q
+// selects on post status.
+func Find(s Store, ledger Ledger, q Query) ([]model.Event, error) {
+	events, err := s.ListRange(q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	var posted map[string]bool
+	if q.Posted != AnyPostStatus {
+		posted, err = postedKeys(ledger, q.From, q.To)
+		if err != nil {
+			return nil, err
+		}
+	}
+	matched := []model.Event{}
+	for _, event := range events {
+		if !q.matches(event) {
+			continue
+		}
+		if q.Posted == Posted && !posted[event.Key()] || q.Posted == Unposted && posted[event.Key()] {
+			continue
+		}
+		matched = append(matched, event)
+	}
+	return matched, nil
+}
+
+func (q Query) matches(event model.Event) bool {
+	if q.Purpose != "" && event.Purpose.Kind != q.Purpose {
+		return false
+	}
+	if q.Keyword != "" {
+		keyword := strings.ToLower(q.Keyword)
+		if !strings.Contains(strings.ToLower(event.Description), keyword) && !strings.Contains(strings.ToLower(event.RawEventString), keyword) {
+			return false
+		}
+	}
+	if len(q.Colors) == 0 {
+		return true
+	}
+	for _, name := range q.Colors {
+		if hasColor(event, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// colorSeparatorRE matches the separators listed colors are written with, as the parser reads them.
+var colorSeparatorRE = regexp.MustCompile(`(?i)\s*(?:/|,|&|\band\b)\s*`)
+
+func hasColor(event model.Event, name string) bool {
+	wanted := model.LookupColor(name)
+	for _, color := range event.Colors {
+		if color.Name == wanted.Name {
+			return true
+		}
+	}
+	// events stored before colors were parsed only have the colors as listed, e.g. "red/white/blue"
+	for _, listed := range colorSeparatorRE.Split(event.Color, -1) {
+		if model.LookupColor(listed).Name == wanted.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// postedKeys returns the keys of the events with a live post for a night from the month before from through
+// the month after to, as an event may have been posted for a night outside the range.
+func postedKeys(ledger Ledger, from, to time.Time) (map[string]bool, error) {
+	posted := make(map[string]bool)
+	first := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	last := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		posts, err := ledger.ListPosts(m)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range posts {
+			if !post.Deleted {
+				posted[post.EventKey] = true
+			}
+		}
+	}
+	return posted, nil
+}
+
+// Next returns the first event lit on date or a later night, along with that night, for telling followers
+// when the lights are next on when they aren't tonight. It returns ErrNotFound when no stored schedule lists
+// a later night.
+func Next(s Store, date time.Time) (model.Event, time.Time, error) {
+	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	for i := 0; i < nextHorizon; i++ {
+		to := time.Date(from.Year(), from.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+		events, err := s.ListRange(from, to)
+		if err != nil {
+			return model.Event{}, time.Time{}, err
+		}
+		var next model.Event
+		var night time.Time
+		for _, event := range events {
+			first, ok := firstNightFrom(event, from)
+			if ok && (night.IsZero() || first.Before(night)) {
+				next, night = event, first
+			}
+		}
+		if !night.IsZero() {
+			return next, night, nil
+		}
+		from = to.AddDate(0, 0, 1)
+	}
+	return model.Event{}, time.Time{}, ErrNotFound
+}
+
+// firstNightFrom returns the first night of event on or after date.
+func firstNightFrom(event model.Event, date time.Time) (time.Time, bool) {
+	nights := []time.Time{}
+	for _, night := range Nights(event) {
+		if !night.Before(date) {
+			nights = append(nights, night)
+		}
+	}
+	if len(nights) == 0 {
+		return time.Time{}, false
+	}
+	sort.Slice(nights, func(i, j int) bool { return nights[i].Before(nights[j]) })
+	return nights[0], true
+}
+
+// Nights returns the nights event is lit, matching IsLitOn, as dates at midnight UTC.
+func Nights(event model.Event) []time.Time {
+	nights := []time.Time{}
+	for _, night := range litNights(event) {
+		if day, err := time.Parse(time.DateOnly, night); err == nil {
+			nights = append(nights, day)
+		}
+	}
+	return nights
+}
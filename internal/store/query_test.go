@@ -0,0 +1,170 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func queryStore(t *testing.T) *FileStore {
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	dir := t.TempDir()
+	november := &FileStore{path: dir, today: day(11, 1)}
+	require.NoError(t, november.CreateMonth(model.Source{}, []model.Event{
+		{StartTimeStamp: day(11, 5), Color: "Red/White/Blue", Colors: []model.Color{model.LookupColor("red"), model.LookupColor("white"), model.LookupColor("blue")}, Description: "Election Day", RawEventString: "Tuesday, November 5: Red/White/Blue for Election Day"},
+		{StartTimeStamp: day(11, 6), Color: "Teal", Colors: []model.Color{model.LookupColor("teal")}, Description: "Light the World Teal", Purpose: model.Purpose{Kind: model.PurposeCampaign}},
+		{StartTimeStamp: day(11, 28), EndTimeStamp: day(12, 1), Color: "Orange", Description: "Thanksgiving", Purpose: model.Purpose{Kind: model.PurposeCelebration}},
+	}))
+	december := &FileStore{path: dir, today: day(12, 1)}
+	require.NoError(t, december.CreateMonth(model.Source{}, []model.Event{
+		{StartTimeStamp: day(12, 20), Color: "Red and Green", Description: "Winter holidays", Purpose: model.Purpose{Kind: model.PurposeCelebration}},
+	}))
+	require.NoError(t, november.RecordPost(model.Post{EventKey: "2024-11-05", Night: day(11, 5), URI: "at://did:plc:abc/app.bsky.feed.post/1"}))
+	require.NoError(t, november.RecordPost(model.Post{EventKey: "2024-11-06", Night: day(11, 6), URI: "at://did:plc:abc/app.bsky.feed.post/2", Deleted: true}))
+	return november
+}
+
+func TestFind(t *testing.T) {
+	f := queryStore(t)
+	from := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{
+			name:  "range across months",
+			query: Query{From: from, To: to},
+			want:  []string{"2024-11-05", "2024-11-06", "2024-11-28", "2024-12-20"},
+		},
+		{
+			name:  "range within a month",
+			query: Query{From: time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC)},
+			want:  []string{"2024-11-28"},
+		},
+		{
+			name:  "color by palette name",
+			query: Query{From: from, To: to, Colors: []string{"Teal"}},
+			want:  []string{"2024-11-06"},
+		},
+		{
+			name:  "color as written",
+			query: Query{From: from, To: to, Colors: []string{"green", "orange"}},
+			want:  []string{"2024-11-28", "2024-12-20"},
+		},
+		{
+			name:  "keyword in description",
+			query: Query{From: from, To: to, Keyword: "holidays"},
+			want:  []string{"2024-12-20"},
+		},
+		{
+			name:  "keyword in raw listing",
+			query: Query{From: from, To: to, Keyword: "tuesday"},
+			want:  []string{"2024-11-05"},
+		},
+		{
+			name:  "purpose",
+			query: Query{From: from, To: to, Purpose: model.PurposeCelebration},
+			want:  []string{"2024-11-28", "2024-12-20"},
+		},
+		{
+			name:  "posted",
+			query: Query{From: from, To: to, Posted: Posted},
+			want:  []string{"2024-11-05"},
+		},
+		{
+			name:  "unposted counts deleted posts as unposted",
+			query: Query{From: from, To: to, Posted: Unposted, Purpose: model.PurposeCampaign},
+			want:  []string{"2024-11-06"},
+		},
+		{
+			name:  "no match",
+			query: Query{From: from, To: to, Colors: []string{"purple"}},
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Find(f, f, tt.query)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, eventKeys(events))
+		})
+	}
+}
+
+func Test_hasColor(t *testing.T) {
+	tests := []struct {
+		name  string
+		color string
+		want  bool
+	}{
+		{name: "one of several colors", color: "Red/White/Blue", want: true},
+		{name: "colors joined by and", color: "Red and Blue", want: true},
+		{name: "synonym of the color", color: "Royal Blue", want: true},
+		{name: "shade of another color", color: "Light Blue/Navy Blue", want: false},
+		{name: "color within another", color: "shades of blue and white", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, hasColor(model.Event{Color: tt.color}, "blue"))
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	f := queryStore(t)
+	tests := []struct {
+		name      string
+		date      time.Time
+		wantKey   string
+		wantNight time.Time
+		wantErr   error
+	}{
+		{
+			name:      "lit tonight",
+			date:      time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC),
+			wantKey:   "2024-11-05",
+			wantNight: time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "dark tonight",
+			date:      time.Date(2024, 11, 7, 0, 0, 0, 0, time.UTC),
+			wantKey:   "2024-11-28",
+			wantNight: time.Date(2024, 11, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "during a run of nights",
+			date:      time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC),
+			wantKey:   "2024-11-28",
+			wantNight: time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "in the following month",
+			date:      time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC),
+			wantKey:   "2024-12-20",
+			wantNight: time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "after the last schedule",
+			date:    time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC),
+			wantErr: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, night, err := Next(f, tt.date)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantKey, event.Key())
+			require.Equal(t, tt.wantNight, night)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"city-hall-lights/internal/model"
+)
+
+// changesDir holds the log of changes made to each month's events after its schedule was first stored.
+const changesDir = "changes"
+
+// Diff compares the stored events of a month with a later scrape of its schedule. Events are matched by
+// where they are listed, then listings left over on both sides with the same description are matched as
+// rescheduled. Changes are keyed by the stored event, and an event whose color and description both changed
+// is reported once for each.
+func Diff(stored, scraped []model.Event) []model.Change {
+	now := time.Now()
+	changes := []model.Change{}
+	scrapedByKey := make(map[string]model.Event)
+	for _, event := range scraped {
+		scrapedByKey[event.ListingKey()] = event
+	}
+	storedByKey := make(map[string]bool)
+	for _, event := range stored {
+		storedByKey[event.ListingKey()] = true
+	}
+	added := []model.Event{}
+	for _, event := range scraped {
+		if !storedByKey[event.ListingKey()] {
+			added = append(added, event)
+		}
+	}
+	for _, event := range stored {
+		after, ok := scrapedByKey[event.ListingKey()]
+		if !ok {
+			// a listing whose first night moved no longer matches where it was listed
+			i := slices.IndexFunc(added, func(after model.Event) bool {
+				return after.Description == event.Description
+			})
+			if i < 0 {
+				changes = append(changes, model.Change{Kind: model.ChangeRemoved, Key: event.Key(), Before: &event, DetectedAt: now})
+				continue
+			}
+			after = added[i]
+			added = slices.Delete(added, i, i+1)
+		}
+		changes = append(changes, changed(event, after, now)...)
+	}
+	for _, event := range added {
+		changes = append(changes, model.Change{Kind: model.ChangeAdded, Key: event.Key(), After: &event, DetectedAt: now})
+	}
+	return changes
+}
+
+// changed returns a change for each way after differs from before. after keeps the key of before, so that it
+// replaces the stored event and posts, revisions and calendar entries still find it.
+func changed(before, after model.Event, now time.Time) []model.Change {
+	after.ID = ""
+	if after.ListingKey() != before.Key() {
+		after.ID = before.Key()
+	}
+	kinds := []model.ChangeKind{}
+	if !strings.EqualFold(before.Color, after.Color) {
+		kinds = append(kinds, model.ChangeRecolored)
+	}
+	if before.Description != after.Description {
+		kinds = append(kinds, model.ChangeRedescribed)
+	}
+	if !sameNights(before, after) {
+		kinds = append(kinds, model.ChangeRescheduled)
+	}
+	changes := []model.Change{}
+	for _, kind := range kinds {
+		changes = append(changes, model.Change{Kind: kind, Key: before.Key(), Before: &before, After: &after, DetectedAt: now})
+	}
+	return changes
+}
+
+func sameNights(a, b model.Event) bool {
+	if !a.StartTimeStamp.Equal(b.StartTimeStamp) || !a.EndTimeStamp.Equal(b.EndTimeStamp) || len(a.Nights) != len(b.Nights) {
+		return false
+	}
+	for i := range a.Nights {
+		if !a.Nights[i].Equal(b.Nights[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reconcile brings the stored events of a backend's month in line with a later scrape of its schedule from
+// source, applying each change through Create, Update and Delete, appending it to the month's change log and
+// recording the new version of each changed event. Manual overrides are kept until the listing they override
+// changes. The month is read and written under one lock.
+func Reconcile(b Backend, source model.Source, scraped []model.Event) ([]model.Change, error) {
+	var changes []model.Change
+	err := locked(b, func(b Backend) error {
+		stored, err := b.ReadMonth()
+		if err != nil {
+			return err
+		}
+		revisions, err := b.ListRevisions(b.Month())
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		diff := keepOverrides(revisions, Diff(stored, scraped))
+		for _, change := range diff {
+			switch change.Kind {
+			case model.ChangeAdded:
+				err = b.Create(*change.After)
+			case model.ChangeRemoved:
+				err = b.Delete(*change.Before)
+			default:
+				err = b.Update(*change.After)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to apply %s change to %s: %w", change.Kind, change.Key, err)
+			}
+		}
+		changes = diff
+		if len(changes) == 0 {
+			return nil
+		}
+		if err = b.LogChanges(changes); err != nil {
+			return err
+		}
+		return b.RecordRevisions(changedRevisions(changes, source))
+	})
+	if err != nil || len(changes) == 0 {
+		return changes, err
+	}
+	return changes, commit(b, changeSummary(b.Month(), changes))
+}
+
+// keepOverrides drops the changes that would undo a manual override while the schedule still lists the event as
+// it did when it was overridden. Once sf.gov changes the listing, the schedule wins again.
+func keepOverrides(revisions []model.Revision, changes []model.Change) []model.Change {
+	overridden := make(map[string]bool)
+	listed := make(map[string]*model.Event)
+	for _, revision := range revisions {
+		overridden[revision.Key] = revision.Reason == model.RevisionManual
+		if revision.Reason != model.RevisionManual {
+			listed[revision.Key] = revision.Event
+		}
+	}
+	kept := []model.Change{}
+	for _, change := range changes {
+		if overridden[change.Key] && sameListing(listed[change.Key], change.After) {
+			continue
+		}
+		kept = append(kept, change)
+	}
+	return kept
+}
+
+// sameListing reports whether two versions of a listing, either of which may be missing from the schedule,
+// are the same.
+func sameListing(a, b *model.Event) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return len(changed(*a, *b, time.Time{})) == 0
+}
+
+// changedRevisions returns one revision for each event changes touch, however many ways it changed.
+func changedRevisions(changes []model.Change, source model.Source) []model.Revision {
+	now := time.Now()
+	revisions := []model.Revision{}
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		if seen[change.Key] {
+			continue
+		}
+		seen[change.Key] = true
+		revisions = append(revisions, model.Revision{
+			Key:        change.Key,
+			Event:      change.After,
+			Reason:     model.RevisionScrape,
+			SourceHash: source.Hash,
+			FetchedAt:  source.ScrapedAt,
+			RecordedAt: now,
+		})
+	}
+	return revisions
+}
+
+// LogChanges appends changes to the current month's change log.
+func (f *FileStore) LogChanges(changes []model.Change) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	logged, err := f.ListChanges(f.today)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return writeJSONAtomic(generateFilename(f.today, filepath.Join(f.path, changesDir)), append(logged, changes...), false)
+}
+
+// ListChanges returns the change log of the month of date, oldest first.
+func (f *FileStore) ListChanges(date time.Time) ([]model.Change, error) {
+	filename := generateFilename(date, filepath.Join(f.path, changesDir))
+	if err := validateFilename(filename); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var changes []model.Change
+	decoder := json.NewDecoder(file)
+	if err = decoder.Decode(&changes); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	nov := func(day int, color, description string, nights ...int) model.Event {
+		event := model.Event{
+			StartTimeStamp: time.Date(2024, 11, day, 0, 0, 0, 0, time.UTC),
+			Color:          color,
+			Description:    description,
+		}
+		for _, night := range nights {
+			event.Nights = append(event.Nights, time.Date(2024, 11, night, 0, 0, 0, 0, time.UTC))
+		}
+		return event
+	}
+	second := func(event model.Event) model.Event {
+		event.Seq = 1
+		return event
+	}
+	tests := []struct {
+		name    string
+		stored  []model.Event
+		scraped []model.Event
+		want    []model.ChangeKind
+		wantKey []string
+	}{
+		{
+			name:    "unchanged schedule",
+			stored:  []model.Event{nov(5, "red/white/blue", "Election Day")},
+			scraped: []model.Event{nov(5, "Red/White/Blue", "Election Day")},
+			want:    []model.ChangeKind{},
+			wantKey: []string{},
+		},
+		{
+			name:    "added and removed",
+			stored:  []model.Event{nov(5, "red", "Election Day"), nov(6, "teal", "Light the World Teal")},
+			scraped: []model.Event{nov(5, "red", "Election Day"), nov(9, "purple", "Hospitality industry")},
+			want:    []model.ChangeKind{model.ChangeRemoved, model.ChangeAdded},
+			wantKey: []string{"2024-11-06", "2024-11-09"},
+		},
+		{
+			name:    "recolored",
+			stored:  []model.Event{nov(6, "teal", "Light the World Teal")},
+			scraped: []model.Event{nov(6, "purple", "Light the World Teal")},
+			want:    []model.ChangeKind{model.ChangeRecolored},
+			wantKey: []string{"2024-11-06"},
+		},
+		{
+			name:    "recolored and re-described",
+			stored:  []model.Event{nov(6, "teal", "Light the World Teal")},
+			scraped: []model.Event{nov(6, "purple", "Alzheimer's Awareness")},
+			want:    []model.ChangeKind{model.ChangeRecolored, model.ChangeRedescribed},
+			wantKey: []string{"2024-11-06", "2024-11-06"},
+		},
+		{
+			name:    "two listings on the same night",
+			stored:  []model.Event{nov(20, "blue/pink/white", "Transgender Day of Remembrance")},
+			scraped: []model.Event{nov(20, "blue/pink/white", "Transgender Day of Remembrance"), second(nov(20, "blue/pink/white", "Transgender Awareness Week"))},
+			want:    []model.ChangeKind{model.ChangeAdded},
+			wantKey: []string{"2024-11-20#2"},
+		},
+		{
+			name:    "first night moved",
+			stored:  []model.Event{nov(6, "teal", "Light the World Teal")},
+			scraped: []model.Event{nov(8, "teal", "Light the World Teal")},
+			want:    []model.ChangeKind{model.ChangeRescheduled},
+			wantKey: []string{"2024-11-06"},
+		},
+		{
+			name:    "listing in the same place matched before a moved one",
+			stored:  []model.Event{nov(6, "teal", "Light the World Teal")},
+			scraped: []model.Event{nov(6, "purple", "Hospitality industry"), nov(8, "teal", "Light the World Teal")},
+			want:    []model.ChangeKind{model.ChangeRecolored, model.ChangeRedescribed, model.ChangeAdded},
+			wantKey: []string{"2024-11-06", "2024-11-06", "2024-11-08"},
+		},
+		{
+			name:    "rescheduled",
+			stored:  []model.Event{nov(1, "red", "Election", 1, 2, 3)},
+			scraped: []model.Event{nov(1, "red", "Election", 1, 2, 3, 4, 5, 6)},
+			want:    []model.ChangeKind{model.ChangeRescheduled},
+			wantKey: []string{"2024-11-01"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(tt.stored, tt.scraped)
+			kinds := []model.ChangeKind{}
+			keys := []string{}
+			for _, change := range got {
+				kinds = append(kinds, change.Kind)
+				keys = append(keys, change.Key)
+			}
+			require.Equal(t, tt.want, kinds)
+			require.Equal(t, tt.wantKey, keys)
+		})
+	}
+}
+
+func TestFileStore_Reconcile(t *testing.T) {
+	f := &FileStore{path: t.TempDir(), today: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, f.CreateMonth(model.Source{}, novemberEvents()))
+
+	scraped := novemberEvents()[1:]
+	scraped[0].Color = "purple"
+	scraped = append(scraped, model.Event{StartTimeStamp: time.Date(2024, 11, 9, 0, 0, 0, 0, time.UTC), Color: "orange"})
+
+	changes, err := Reconcile(f, model.Source{}, scraped)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+
+	got, err := f.ReadMonth()
+	require.NoError(t, err)
+	require.Equal(t, scraped, got)
+
+	logged, err := f.ListChanges(f.today)
+	require.NoError(t, err)
+	require.Len(t, logged, 3)
+
+	// a second run against the same schedule changes nothing and leaves the log alone
+	changes, err = Reconcile(f, model.Source{}, scraped)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+	logged, err = f.ListChanges(f.today)
+	require.NoError(t, err)
+	require.Len(t, logged, 3)
+}
+
+func TestReconcile_rescheduled(t *testing.T) {
+	november := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	moved := time.Date(2024, 11, 8, 0, 0, 0, 0, time.UTC)
+	backends := map[string]Backend{
+		"file":   &FileStore{path: t.TempDir(), today: november},
+		"sqlite": openTestSQLiteStore(t, november),
+	}
+	for name, b := range backends {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, StoreSchedule(b, model.Source{}, novemberEvents()))
+			scraped := novemberEvents()
+			scraped[1].StartTimeStamp = moved
+			changes, err := Reconcile(b, model.Source{}, scraped)
+			require.NoError(t, err)
+			require.Len(t, changes, 1)
+			require.Equal(t, model.ChangeRescheduled, changes[0].Kind)
+
+			// the event keeps its key, so that its posts and history still find it
+			got, err := b.Read(moved)
+			require.NoError(t, err)
+			require.Equal(t, "2024-11-06", got.Key())
+			history, err := History(b, moved)
+			require.NoError(t, err)
+			require.Len(t, history, 2)
+
+			changes, err = Reconcile(b, model.Source{}, scraped)
+			require.NoError(t, err)
+			require.Empty(t, changes)
+		})
+	}
+}
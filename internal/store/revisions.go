@@ -0,0 +1,157 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"city-hall-lights/internal/model"
+)
+
+// revisionsDir holds the append-only log of every version of each month's events.
+const revisionsDir = "revisions"
+
+// RecordRevisions appends revisions to the current month's revision log.
+func (f *FileStore) RecordRevisions(revisions []model.Revision) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return f.appendRevisions(f.today, revisions)
+}
+
+// appendRevisions appends to the revision log of the month of date. The caller holds the lock.
+func (f *FileStore) appendRevisions(date time.Time, revisions []model.Revision) error {
+	logged, err := f.ListRevisions(date)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return writeJSONAtomic(generateFilename(date, filepath.Join(f.path, revisionsDir)), append(logged, revisions...), false)
+}
+
+// ListRevisions returns the revision log of the month of date, oldest first.
+func (f *FileStore) ListRevisions(date time.Time) ([]model.Revision, error) {
+	filename := generateFilename(date, filepath.Join(f.path, revisionsDir))
+	if err := validateFilename(filename); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var revisions []model.Revision
+	decoder := json.NewDecoder(file)
+	if err = decoder.Decode(&revisions); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+	return revisions, nil
+}
+
+// revise returns a revision of each event, recorded now.
+func revise(events []model.Event, reason model.RevisionReason, source model.Source) []model.Revision {
+	now := time.Now()
+	revisions := []model.Revision{}
+	for i := range events {
+		revisions = append(revisions, model.Revision{
+			Key:        events[i].Key(),
+			Event:      &events[i],
+			Reason:     reason,
+			SourceHash: source.Hash,
+			FetchedAt:  source.ScrapedAt,
+			RecordedAt: now,
+		})
+	}
+	return revisions
+}
+
+// StoreSchedule stores the events of a month's schedule as scraped from source, recording the first revision
+// of each.
+func StoreSchedule(b Backend, source model.Source, events []model.Event) error {
+	if err := b.CreateMonth(source, events); err != nil {
+		return err
+	}
+	if err := b.RecordRevisions(revise(events, model.RevisionScrape, source)); err != nil {
+		return err
+	}
+	return commit(b, commitMessage(b.Month(), "%d events published", len(events)))
+}
+
+// Override replaces the stored event with the same key, or adds it, by hand, e.g. when the schedule is known
+// to be wrong. The revision is recorded as a manual override so that it can be told apart from scrapes.
+func Override(b Backend, event model.Event) error {
+	err := locked(b, func(b Backend) error {
+		err := b.Update(event)
+		if errors.Is(err, ErrNotFound) {
+			err = b.Create(event)
+		}
+		if err != nil {
+			return err
+		}
+		return b.RecordRevisions(revise([]model.Event{event}, model.RevisionManual, model.Source{}))
+	})
+	if err != nil {
+		return err
+	}
+	return commit(b, commitMessage(b.Month(), "%s overridden by hand", event.Key()))
+}
+
+// History returns the revisions of every event that was lit on night in any of its versions, oldest first, to
+// show how the night's listing evolved.
+func History(b Backend, night time.Time) ([]model.Revision, error) {
+	first := time.Date(night.Year(), night.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	revisions := []model.Revision{}
+	for m := first; m.Before(first.AddDate(0, 3, 0)); m = m.AddDate(0, 1, 0) {
+		logged, err := b.ListRevisions(m)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, logged...)
+	}
+	keys := make(map[string]bool)
+	for _, revision := range revisions {
+		if revision.Event != nil && IsLitOn(*revision.Event, night) {
+			keys[revision.Key] = true
+		}
+	}
+	history := []model.Revision{}
+	for _, revision := range revisions {
+		if keys[revision.Key] {
+			history = append(history, revision)
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].RecordedAt.Before(history[j].RecordedAt)
+	})
+	return history, nil
+}
+
+// ListingAt returns the version of the listing for night that was stored at a given time, e.g. when a post
+// went out, from the history of the night. It reports false when no event was stored as lit on night then.
+func ListingAt(history []model.Revision, night, at time.Time) (model.Event, bool) {
+	current := make(map[string]*model.Event)
+	keys := []string{}
+	for _, revision := range history {
+		if revision.RecordedAt.After(at) {
+			continue
+		}
+		if _, ok := current[revision.Key]; !ok {
+			keys = append(keys, revision.Key)
+		}
+		current[revision.Key] = revision.Event
+	}
+	for _, key := range keys {
+		if event := current[key]; event != nil && IsLitOn(*event, night) {
+			return *event, true
+		}
+	}
+	return model.Event{}, false
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	november := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	night := time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC)
+	first := model.Source{ScrapedAt: time.Date(2024, 11, 1, 8, 0, 0, 0, time.UTC), Hash: "first"}
+	second := model.Source{ScrapedAt: time.Date(2024, 11, 2, 8, 0, 0, 0, time.UTC), Hash: "second"}
+	backends := map[string]Backend{
+		"file":   &FileStore{path: t.TempDir(), today: november},
+		"sqlite": openTestSQLiteStore(t, november),
+	}
+	for name, b := range backends {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, StoreSchedule(b, first, novemberEvents()))
+			history, err := History(b, night)
+			require.NoError(t, err)
+			require.Len(t, history, 1)
+			require.Equal(t, model.RevisionScrape, history[0].Reason)
+			require.Equal(t, "first", history[0].SourceHash)
+			require.Equal(t, first.ScrapedAt, history[0].FetchedAt)
+			posted := time.Now()
+
+			scraped := novemberEvents()
+			scraped[1].Color = "purple"
+			_, err = Reconcile(b, second, scraped)
+			require.NoError(t, err)
+			manual := scraped[1]
+			manual.Description = "corrected by hand"
+			require.NoError(t, Override(b, manual))
+			_, err = Reconcile(b, second, scraped[:1])
+			require.NoError(t, err)
+
+			history, err = History(b, night)
+			require.NoError(t, err)
+			reasons := []model.RevisionReason{}
+			for _, revision := range history {
+				require.Equal(t, "2024-11-06", revision.Key)
+				reasons = append(reasons, revision.Reason)
+			}
+			require.Equal(t, []model.RevisionReason{model.RevisionScrape, model.RevisionScrape, model.RevisionManual, model.RevisionScrape}, reasons)
+			require.Equal(t, "purple", history[1].Event.Color)
+			require.Equal(t, "second", history[1].SourceHash)
+			require.Equal(t, "corrected by hand", history[2].Event.Description)
+			require.Empty(t, history[2].SourceHash)
+			require.Nil(t, history[3].Event)
+
+			listing, ok := ListingAt(history, night, posted)
+			require.True(t, ok)
+			require.Equal(t, novemberEvents()[1].Color, listing.Color)
+			listing, ok = ListingAt(history, night, history[2].RecordedAt)
+			require.True(t, ok)
+			require.Equal(t, "corrected by hand", listing.Description)
+			_, ok = ListingAt(history, night, history[3].RecordedAt)
+			require.False(t, ok)
+
+			other, err := History(b, time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC))
+			require.NoError(t, err)
+			require.Empty(t, other)
+		})
+	}
+}
+
+func TestReconcile_keepsOverrides(t *testing.T) {
+	november := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	night := time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC)
+	backends := map[string]Backend{
+		"file":   &FileStore{path: t.TempDir(), today: november},
+		"sqlite": openTestSQLiteStore(t, november),
+	}
+	for name, b := range backends {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, StoreSchedule(b, model.Source{}, novemberEvents()))
+			manual := novemberEvents()[1]
+			manual.Color = "purple"
+			require.NoError(t, Override(b, manual))
+			added := model.Event{StartTimeStamp: time.Date(2024, 11, 9, 0, 0, 0, 0, time.UTC), Color: "orange", Description: "added by hand"}
+			require.NoError(t, Override(b, added))
+
+			// the schedule still lists the event as it was overridden
+			changes, err := Reconcile(b, model.Source{}, novemberEvents())
+			require.NoError(t, err)
+			require.Empty(t, changes)
+			got, err := b.Read(night)
+			require.NoError(t, err)
+			require.Equal(t, "purple", got.Color)
+			_, err = b.Read(added.StartTimeStamp)
+			require.NoError(t, err)
+
+			// sf.gov changed the listing since
+			scraped := novemberEvents()
+			scraped[1].Color = "gold"
+			changes, err = Reconcile(b, model.Source{}, scraped)
+			require.NoError(t, err)
+			require.Len(t, changes, 1)
+			require.Equal(t, model.ChangeRecolored, changes[0].Kind)
+			got, err = b.Read(night)
+			require.NoError(t, err)
+			require.Equal(t, "gold", got.Color)
+		})
+	}
+}
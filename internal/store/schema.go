@@ -0,0 +1,157 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"city-hall-lights/internal/model"
+)
+
+// SchemaVersion is the version of the month file format this build writes.
+const SchemaVersion = 1
+
+// MonthFile is the envelope of a month's events on disk, recording which format the file uses and the scrape
+// its events came from.
+type MonthFile struct {
+	SchemaVersion int `json:"schema_version"`
+	model.Source
+	Events []model.Event `json:"events"`
+}
+
+// fileMigrations upgrade the JSON of a month file from the version at their index to the next. Version 0 is
+// the bare array of events written before files had an envelope. Append new migrations; never edit one that
+// has shipped.
+var fileMigrations = []func(data []byte) ([]byte, error){
+	// 0 → 1: wrap the events in an envelope, with the source unknown
+	func(data []byte) ([]byte, error) {
+		var events []model.Event
+		if err := json.Unmarshal(data, &events); err != nil {
+			return nil, err
+		}
+		return json.Marshal(MonthFile{SchemaVersion: 1, Events: events})
+	},
+}
+
+// decodeMonthFile decodes a month file of any version, upgrading it to SchemaVersion. It also returns the
+// version the file was written in.
+func decodeMonthFile(data []byte) (MonthFile, int, error) {
+	version, err := schemaVersion(data)
+	if err != nil {
+		return MonthFile{}, 0, err
+	}
+	if version > SchemaVersion {
+		return MonthFile{}, version, fmt.Errorf("schema version %d is newer than %d, upgrade city-hall-lights", version, SchemaVersion)
+	}
+	upgraded := data
+	for v := version; v < SchemaVersion; v++ {
+		if upgraded, err = fileMigrations[v](upgraded); err != nil {
+			return MonthFile{}, version, fmt.Errorf("failed to migrate from schema version %d: %w", v, err)
+		}
+	}
+	var file MonthFile
+	if err = json.Unmarshal(upgraded, &file); err != nil {
+		return MonthFile{}, version, err
+	}
+	if file.Events == nil {
+		file.Events = []model.Event{}
+	}
+	return file, version, nil
+}
+
+func schemaVersion(data []byte) (int, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return 0, nil
+	}
+	var header struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(trimmed, &header); err != nil {
+		return 0, err
+	}
+	if header.SchemaVersion == nil {
+		return 0, fmt.Errorf("missing schema_version")
+	}
+	return *header.SchemaVersion, nil
+}
+
+func readMonthFile(date time.Time, path string) (MonthFile, int, error) {
+	filename := generateFilename(date, path)
+	if err := validateFilename(filename); err != nil {
+		return MonthFile{}, 0, err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return MonthFile{}, 0, err
+	}
+	file, version, err := decodeMonthFile(data)
+	if err != nil {
+		return MonthFile{}, version, fmt.Errorf("failed to decode json: %w", err)
+	}
+	return file, version, nil
+}
+
+// writeMonthFile writes file as the month file of date in the current schema version. With exclusive set it
+// fails if the month is already stored.
+func writeMonthFile(date time.Time, path string, file MonthFile, exclusive bool) error {
+	filename := generateFilename(date, path)
+	if err := validateFilename(filename); err != nil {
+		return err
+	}
+	file.SchemaVersion = SchemaVersion
+	return writeJSONAtomic(filename, file, exclusive)
+}
+
+// MonthSource returns the scrape the current month's schedule was stored from.
+func (f *FileStore) MonthSource() (model.Source, error) {
+	file, _, err := readMonthFile(f.today, f.path)
+	if err != nil {
+		return model.Source{}, err
+	}
+	return file.Source, nil
+}
+
+// Migrate rewrites every month file written in an older schema version in the current one, and records the
+// events of months without a revision log as a baseline revision. Files are upgraded whenever they are read
+// anyway; this saves redoing it on every read. It returns the months migrated.
+func (f *FileStore) Migrate() ([]time.Time, error) {
+	unlock, err := f.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	months, err := f.Months()
+	if err != nil {
+		return nil, err
+	}
+	migrated := []time.Time{}
+	for _, month := range months {
+		file, version, err := readMonthFile(month, f.path)
+		if err != nil {
+			return migrated, fmt.Errorf("%s: %w", month.Format(time.DateOnly), err)
+		}
+		_, err = f.ListRevisions(month)
+		baseline := os.IsNotExist(err)
+		if err != nil && !baseline {
+			return migrated, err
+		}
+		if version == SchemaVersion && !baseline {
+			continue
+		}
+		if version != SchemaVersion {
+			if err = writeMonthFile(month, f.path, file, false); err != nil {
+				return migrated, err
+			}
+		}
+		if baseline {
+			if err = f.appendRevisions(month, revise(file.Events, model.RevisionMigration, file.Source)); err != nil {
+				return migrated, err
+			}
+		}
+		migrated = append(migrated, month)
+	}
+	return migrated, nil
+}
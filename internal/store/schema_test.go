@@ -0,0 +1,138 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMonthFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		want        MonthFile
+		wantVersion int
+		wantErr     bool
+	}{
+		{
+			name:        "bare array is upgraded",
+			data:        `[{"date_string": "Tuesday, November 5, 2024", "start_timestamp": "2024-11-05T00:00:00Z", "color": "red"}]`,
+			want:        MonthFile{SchemaVersion: 1, Events: []model.Event{{DateString: "Tuesday, November 5, 2024", StartTimeStamp: time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC), Color: "red"}}},
+			wantVersion: 0,
+		},
+		{
+			name:        "empty bare array",
+			data:        "[]\n",
+			want:        MonthFile{SchemaVersion: 1, Events: []model.Event{}},
+			wantVersion: 0,
+		},
+		{
+			name: "envelope",
+			data: `{"schema_version": 1, "scraped_at": "2024-11-01T15:04:05Z", "source_url": "https://www.sf.gov/location/san-francisco-city-hall", "source_hash": "abc123", "events": []}`,
+			want: MonthFile{
+				SchemaVersion: 1,
+				Source:        model.Source{ScrapedAt: time.Date(2024, 11, 1, 15, 4, 5, 0, time.UTC), URL: "https://www.sf.gov/location/san-francisco-city-hall", Hash: "abc123"},
+				Events:        []model.Event{},
+			},
+			wantVersion: 1,
+		},
+		{
+			name:        "newer schema version",
+			data:        `{"schema_version": 2, "events": []}`,
+			wantVersion: 2,
+			wantErr:     true,
+		},
+		{
+			name:    "object without a version",
+			data:    `{"events": []}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, version, err := decodeMonthFile([]byte(tt.data))
+			require.Equal(t, tt.wantVersion, version)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFileStore_Migrate(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"2024-11-01.json", "2024-12-01.json"} {
+		data, err := os.ReadFile(filepath.Join("file-test-fixtures/cross-month-cases", name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0644))
+	}
+	f := &FileStore{path: dir, today: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)}
+	before, err := f.ListRange(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	migrated, err := f.Migrate()
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)}, migrated)
+	_, version, err := readMonthFile(f.today, dir)
+	require.NoError(t, err)
+	require.Equal(t, SchemaVersion, version)
+	events, err := f.ReadMonth()
+	require.NoError(t, err)
+	revisions, err := f.ListRevisions(f.today)
+	require.NoError(t, err)
+	require.Len(t, revisions, len(events))
+	require.Equal(t, model.RevisionMigration, revisions[0].Reason)
+
+	after, err := f.ListRange(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+
+	migrated, err = f.Migrate()
+	require.NoError(t, err)
+	require.Empty(t, migrated)
+}
+
+func TestMonthSource(t *testing.T) {
+	november := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	source := model.Source{ScrapedAt: time.Date(2024, 11, 1, 15, 4, 5, 0, time.UTC), URL: "https://www.sf.gov/location/san-francisco-city-hall", Hash: "abc123"}
+	backends := map[string]Backend{
+		"file":   &FileStore{path: t.TempDir(), today: november},
+		"sqlite": openTestSQLiteStore(t, november),
+	}
+	for name, b := range backends {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, b.CreateMonth(source, novemberEvents()))
+			event := novemberEvents()[0]
+			event.Color = "purple"
+			require.NoError(t, b.Update(event))
+
+			got, err := b.MonthSource()
+			require.NoError(t, err)
+			require.Equal(t, source, got)
+		})
+	}
+}
+
+func TestCreateMonth_duplicateKey(t *testing.T) {
+	november := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	backends := map[string]Backend{
+		"file":   &FileStore{path: t.TempDir(), today: november},
+		"sqlite": openTestSQLiteStore(t, november),
+	}
+	for name, b := range backends {
+		t.Run(name, func(t *testing.T) {
+			events := append(novemberEvents(), novemberEvents()[1])
+			require.ErrorIs(t, b.CreateMonth(model.Source{}, events), ErrExists)
+			exists, err := b.HasMonth()
+			require.NoError(t, err)
+			require.False(t, exists)
+		})
+	}
+}
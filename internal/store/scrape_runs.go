@@ -0,0 +1,47 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"city-hall-lights/internal/model"
+)
+
+// runsDir holds the log of each month's fetches of the lighting schedule page.
+const runsDir = "runs"
+
+// RecordScrapeRun appends a fetch of the page to the log of the month it was made in.
+func (f *FileStore) RecordScrapeRun(run model.ScrapeRun) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	runs, err := f.ListScrapeRuns(run.FetchedAt)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return writeJSONAtomic(generateFilename(run.FetchedAt, filepath.Join(f.path, runsDir)), append(runs, run), false)
+}
+
+// ListScrapeRuns returns the fetches of the page made in the month of date, oldest first.
+func (f *FileStore) ListScrapeRuns(date time.Time) ([]model.ScrapeRun, error) {
+	filename := generateFilename(date, filepath.Join(f.path, runsDir))
+	if err := validateFilename(filename); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var runs []model.ScrapeRun
+	decoder := json.NewDecoder(file)
+	if err = decoder.Decode(&runs); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqliteMigrations are applied in order, each once, and recorded in schema_migrations. Append new
+// migrations; never edit one that has shipped.
+var sqliteMigrations = []string{
+	// 1: events of each month's schedule, indexed by the nights they are lit
+	`CREATE TABLE months (
+		month      TEXT PRIMARY KEY,
+		created_at TEXT NOT NULL
+	);
+	CREATE TABLE events (
+		month            TEXT NOT NULL REFERENCES months (month),
+		key              TEXT NOT NULL,
+		date_string      TEXT NOT NULL,
+		start_timestamp  TEXT NOT NULL,
+		end_timestamp    TEXT NOT NULL,
+		nights           TEXT NOT NULL,
+		color            TEXT NOT NULL,
+		colors           TEXT NOT NULL,
+		description      TEXT NOT NULL,
+		purpose          TEXT NOT NULL,
+		raw_event_string TEXT NOT NULL,
+		PRIMARY KEY (month, key)
+	);
+	CREATE INDEX events_key ON events (key);
+	CREATE TABLE nights (
+		month TEXT NOT NULL,
+		key   TEXT NOT NULL,
+		night TEXT NOT NULL,
+		PRIMARY KEY (month, key, night),
+		FOREIGN KEY (month, key) REFERENCES events (month, key) ON DELETE CASCADE
+	);
+	CREATE INDEX nights_night ON nights (night);`,
+
+	// 2: bookkeeping kept alongside the schedule
+	`CREATE TABLE unparsed_lines (
+		month            TEXT NOT NULL,
+		position         INTEGER NOT NULL,
+		raw_event_string TEXT NOT NULL,
+		stage            TEXT NOT NULL,
+		offending        TEXT NOT NULL,
+		suggestion       TEXT NOT NULL,
+		error            TEXT NOT NULL,
+		PRIMARY KEY (month, position)
+	);
+	CREATE TABLE changes (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		month       TEXT NOT NULL,
+		kind        TEXT NOT NULL,
+		key         TEXT NOT NULL,
+		before      TEXT,
+		after       TEXT,
+		detected_at TEXT NOT NULL
+	);
+	CREATE TABLE ledger (
+		uri       TEXT PRIMARY KEY,
+		event_key TEXT NOT NULL,
+		night     TEXT NOT NULL,
+		cid       TEXT NOT NULL,
+		blob_cid  TEXT NOT NULL,
+		posted_at TEXT NOT NULL,
+		reply_to  TEXT NOT NULL,
+		deleted   INTEGER NOT NULL
+	);
+	CREATE INDEX ledger_night ON ledger (night);
+	CREATE TABLE scrape_runs (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		fetched_at TEXT NOT NULL,
+		url        TEXT NOT NULL,
+		hash       TEXT NOT NULL,
+		month      TEXT NOT NULL,
+		cached     INTEGER NOT NULL,
+		events     INTEGER NOT NULL,
+		rejected   INTEGER NOT NULL
+	);`,
+
+	// 3: the scrape each month's schedule was stored from
+	`ALTER TABLE months ADD COLUMN scraped_at TEXT NOT NULL DEFAULT '0001-01-01T00:00:00Z';
+	ALTER TABLE months ADD COLUMN source_url TEXT NOT NULL DEFAULT '';
+	ALTER TABLE months ADD COLUMN source_hash TEXT NOT NULL DEFAULT '';`,
+
+	// 4: every version of each month's events
+	`CREATE TABLE revisions (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		month       TEXT NOT NULL,
+		key         TEXT NOT NULL,
+		event       TEXT,
+		reason      TEXT NOT NULL,
+		source_hash TEXT NOT NULL,
+		fetched_at  TEXT NOT NULL,
+		recorded_at TEXT NOT NULL
+	);
+	CREATE INDEX revisions_month ON revisions (month);`,
+
+	// 5: what tells apart listings starting on the same night, and the key kept by a rescheduled event
+	`ALTER TABLE events ADD COLUMN seq INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE events ADD COLUMN id TEXT NOT NULL DEFAULT '';`,
+}
+
+// migrate brings the database schema up to date.
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	for i := current; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+		if _, err = tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+			i+1, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
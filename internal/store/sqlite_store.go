@@ -0,0 +1,548 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"city-hall-lights/internal/model"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore keeps the schedule in an SQLite database, using a pure Go driver so that no cgo toolchain is
+// needed. Like FileStore it is scoped to the schedule of the current month; events are kept per schedule
+// month, with a row in nights for every date they are lit.
+type SQLiteStore struct {
+	db    *sql.DB
+	today time.Time
+}
+
+// OpenSQLiteStore opens the database at path, creating it and applying any pending migrations.
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err = migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db, today: time.Now()}, nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// At returns a copy of the store that treats date as today.
+func (s *SQLiteStore) At(date time.Time) *SQLiteStore {
+	return &SQLiteStore{db: s.db, today: date}
+}
+
+// ForMonth returns a copy of the store scoped to the schedule of month.
+func (s *SQLiteStore) ForMonth(month time.Time) Backend {
+	return s.At(month)
+}
+
+func (s *SQLiteStore) Month() time.Time {
+	return time.Date(s.today.Year(), s.today.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func monthKey(date time.Time) string {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+}
+
+func (s *SQLiteStore) HasMonth() (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM months WHERE month = ?`, monthKey(s.today)).Scan(&n)
+	return n > 0, err
+}
+
+// CreateMonth stores the events of the current month's schedule and the scrape they came from, failing if the
+// month is already stored.
+func (s *SQLiteStore) CreateMonth(source model.Source, events []model.Event) error {
+	if err := checkKeys(events); err != nil {
+		return err
+	}
+	month := monthKey(s.today)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var n int
+	if err = tx.QueryRow(`SELECT COUNT(*) FROM months WHERE month = ?`, month).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("month %s already stored: %w", month, ErrExists)
+	}
+	if _, err = tx.Exec(`INSERT INTO months (month, created_at, scraped_at, source_url, source_hash) VALUES (?, ?, ?, ?, ?)`,
+		month, time.Now().UTC().Format(time.RFC3339), source.ScrapedAt.UTC().Format(time.RFC3339Nano), source.URL, source.Hash); err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err = insertEvent(tx, month, event); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Create(event model.Event) error {
+	month := monthKey(s.today)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(`INSERT OR IGNORE INTO months (month, created_at) VALUES (?, ?)`, month, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	var n int
+	if err = tx.QueryRow(`SELECT COUNT(*) FROM events WHERE month = ? AND key = ?`, month, event.Key()).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%w: %s", ErrExists, event.Key())
+	}
+	if err = insertEvent(tx, month, event); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Update(event model.Event) error {
+	month := monthKey(s.today)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = deleteEvent(tx, month, event.Key()); err != nil {
+		return err
+	}
+	if err = insertEvent(tx, month, event); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Delete(event model.Event) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = deleteEvent(tx, monthKey(s.today), event.Key()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) MonthSource() (model.Source, error) {
+	var source model.Source
+	var scrapedAt string
+	err := s.db.QueryRow(`SELECT scraped_at, source_url, source_hash FROM months WHERE month = ?`, monthKey(s.today)).
+		Scan(&scrapedAt, &source.URL, &source.Hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Source{}, fmt.Errorf("%w: month %s", ErrNotFound, monthKey(s.today))
+	}
+	if err != nil {
+		return model.Source{}, err
+	}
+	if source.ScrapedAt, err = time.Parse(time.RFC3339Nano, scrapedAt); err != nil {
+		return model.Source{}, err
+	}
+	return source, nil
+}
+
+func (s *SQLiteStore) ReadMonth() ([]model.Event, error) {
+	return s.queryEvents(`SELECT `+eventColumns+` FROM events WHERE month = ? ORDER BY start_timestamp, rowid`, monthKey(s.today))
+}
+
+func (s *SQLiteStore) Read(date time.Time) (model.Event, error) {
+	events, err := s.ListRange(date, date)
+	if err != nil {
+		return model.Event{}, err
+	}
+	if len(events) == 0 {
+		return model.Event{}, ErrNotFound
+	}
+	return events[0], nil
+}
+
+func (s *SQLiteStore) Get(key string) (model.Event, error) {
+	events, err := s.queryEvents(`SELECT `+eventColumns+` FROM events WHERE key = ? ORDER BY month LIMIT 1`, key)
+	if err != nil {
+		return model.Event{}, err
+	}
+	if len(events) == 0 {
+		return model.Event{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return events[0], nil
+}
+
+func (s *SQLiteStore) List(date time.Time) ([]model.Event, error) {
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return s.ListRange(first, first.AddDate(0, 1, -1))
+}
+
+// ListRange returns the events lit on any night from one date through another, listing an event once when
+// the schedules of two months both list it.
+func (s *SQLiteStore) ListRange(from, to time.Time) ([]model.Event, error) {
+	events, err := s.queryEvents(`SELECT `+eventColumns+` FROM events e
+		WHERE EXISTS (
+			SELECT 1 FROM nights n WHERE n.month = e.month AND n.key = e.key AND n.night BETWEEN ? AND ?
+		)
+		ORDER BY e.month, e.start_timestamp, e.rowid`,
+		from.Format(time.DateOnly), to.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	listed := []model.Event{}
+	seen := make(map[string]bool)
+	for _, event := range events {
+		if seen[event.Key()] {
+			continue
+		}
+		seen[event.Key()] = true
+		listed = append(listed, event)
+	}
+	return listed, nil
+}
+
+const eventColumns = `date_string, start_timestamp, end_timestamp, nights, color, colors, description, purpose, raw_event_string, seq, id`
+
+func insertEvent(tx *sql.Tx, month string, event model.Event) error {
+	nights, err := json.Marshal(event.Nights)
+	if err != nil {
+		return err
+	}
+	colors, err := json.Marshal(event.Colors)
+	if err != nil {
+		return err
+	}
+	purpose, err := json.Marshal(event.Purpose)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO events (month, key, `+eventColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		month, event.Key(), event.DateString,
+		event.StartTimeStamp.Format(time.RFC3339Nano), event.EndTimeStamp.Format(time.RFC3339Nano), string(nights),
+		event.Color, string(colors), event.Description, string(purpose), event.RawEventString, event.Seq, event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to insert event %s: %w", event.Key(), err)
+	}
+	for _, night := range litNights(event) {
+		if _, err = tx.Exec(`INSERT OR IGNORE INTO nights (month, key, night) VALUES (?, ?, ?)`, month, event.Key(), night); err != nil {
+			return fmt.Errorf("failed to insert nights of %s: %w", event.Key(), err)
+		}
+	}
+	return nil
+}
+
+func deleteEvent(tx *sql.Tx, month string, key string) error {
+	result, err := tx.Exec(`DELETE FROM events WHERE month = ? AND key = ?`, month, key)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return nil
+}
+
+// litNights returns the dates the event is lit, matching IsLitOn.
+func litNights(event model.Event) []string {
+	nights := []string{}
+	if len(event.Nights) > 0 {
+		for _, night := range event.Nights {
+			nights = append(nights, night.Format(time.DateOnly))
+		}
+		return nights
+	}
+	nights = append(nights, event.StartTimeStamp.Format(time.DateOnly))
+	if event.EndTimeStamp.IsZero() {
+		return nights
+	}
+	day := event.StartTimeStamp.AddDate(0, 0, 1)
+	for !day.After(event.EndTimeStamp) {
+		nights = append(nights, day.Format(time.DateOnly))
+		day = day.AddDate(0, 0, 1)
+	}
+	return nights
+}
+
+func (s *SQLiteStore) queryEvents(query string, args ...any) ([]model.Event, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := []model.Event{}
+	for rows.Next() {
+		var event model.Event
+		var start, end, nights, colors, purpose string
+		if err = rows.Scan(&event.DateString, &start, &end, &nights, &event.Color, &colors, &event.Description,
+			&purpose, &event.RawEventString, &event.Seq, &event.ID); err != nil {
+			return nil, err
+		}
+		if event.StartTimeStamp, err = time.Parse(time.RFC3339Nano, start); err != nil {
+			return nil, err
+		}
+		if event.EndTimeStamp, err = time.Parse(time.RFC3339Nano, end); err != nil {
+			return nil, err
+		}
+		if err = errors.Join(
+			json.Unmarshal([]byte(nights), &event.Nights),
+			json.Unmarshal([]byte(colors), &event.Colors),
+			json.Unmarshal([]byte(purpose), &event.Purpose),
+		); err != nil {
+			return nil, fmt.Errorf("failed to decode event %s: %w", start, err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLiteStore) Quarantine(lines []model.UnparsedLine) error {
+	month := monthKey(s.today)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(`DELETE FROM unparsed_lines WHERE month = ?`, month); err != nil {
+		return err
+	}
+	for i, line := range lines {
+		if _, err = tx.Exec(`INSERT INTO unparsed_lines (month, position, raw_event_string, stage, offending, suggestion, error)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			month, i, line.RawEventString, line.Stage, line.Offending, line.Suggestion, line.Error); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) ListQuarantine(date time.Time) ([]model.UnparsedLine, error) {
+	rows, err := s.db.Query(`SELECT raw_event_string, stage, offending, suggestion, error FROM unparsed_lines
+		WHERE month = ? ORDER BY position`, monthKey(date))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lines := []model.UnparsedLine{}
+	for rows.Next() {
+		var line model.UnparsedLine
+		if err = rows.Scan(&line.RawEventString, &line.Stage, &line.Offending, &line.Suggestion, &line.Error); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+func (s *SQLiteStore) LogChanges(changes []model.Change) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, change := range changes {
+		before, err := marshalOptional(change.Before)
+		if err != nil {
+			return err
+		}
+		after, err := marshalOptional(change.After)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(`INSERT INTO changes (month, kind, key, before, after, detected_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			monthKey(s.today), string(change.Kind), change.Key, before, after, change.DetectedAt.Format(time.RFC3339Nano)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) RecordRevisions(revisions []model.Revision) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, revision := range revisions {
+		event, err := marshalOptional(revision.Event)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(`INSERT INTO revisions (month, key, event, reason, source_hash, fetched_at, recorded_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			monthKey(s.today), revision.Key, event, string(revision.Reason), revision.SourceHash,
+			revision.FetchedAt.Format(time.RFC3339Nano), revision.RecordedAt.Format(time.RFC3339Nano)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) ListRevisions(date time.Time) ([]model.Revision, error) {
+	rows, err := s.db.Query(`SELECT key, event, reason, source_hash, fetched_at, recorded_at FROM revisions WHERE month = ? ORDER BY id`, monthKey(date))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	revisions := []model.Revision{}
+	for rows.Next() {
+		var revision model.Revision
+		var event sql.NullString
+		var fetchedAt, recordedAt string
+		if err = rows.Scan(&revision.Key, &event, &revision.Reason, &revision.SourceHash, &fetchedAt, &recordedAt); err != nil {
+			return nil, err
+		}
+		if revision.FetchedAt, err = time.Parse(time.RFC3339Nano, fetchedAt); err != nil {
+			return nil, err
+		}
+		if revision.RecordedAt, err = time.Parse(time.RFC3339Nano, recordedAt); err != nil {
+			return nil, err
+		}
+		if event.Valid {
+			revision.Event = &model.Event{}
+			if err = json.Unmarshal([]byte(event.String), revision.Event); err != nil {
+				return nil, err
+			}
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+func marshalOptional(event *model.Event) (sql.NullString, error) {
+	if event == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(event)
+	return sql.NullString{String: string(data), Valid: true}, err
+}
+
+func (s *SQLiteStore) ListChanges(date time.Time) ([]model.Change, error) {
+	rows, err := s.db.Query(`SELECT kind, key, before, after, detected_at FROM changes WHERE month = ? ORDER BY id`, monthKey(date))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	changes := []model.Change{}
+	for rows.Next() {
+		var change model.Change
+		var before, after sql.NullString
+		var detectedAt string
+		if err = rows.Scan(&change.Kind, &change.Key, &before, &after, &detectedAt); err != nil {
+			return nil, err
+		}
+		if change.DetectedAt, err = time.Parse(time.RFC3339Nano, detectedAt); err != nil {
+			return nil, err
+		}
+		if before.Valid {
+			change.Before = &model.Event{}
+			if err = json.Unmarshal([]byte(before.String), change.Before); err != nil {
+				return nil, err
+			}
+		}
+		if after.Valid {
+			change.After = &model.Event{}
+			if err = json.Unmarshal([]byte(after.String), change.After); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// RecordPost stores a published post in the ledger, replacing an earlier record of the same post.
+func (s *SQLiteStore) RecordPost(post model.Post) error {
+	_, err := s.db.Exec(`INSERT INTO ledger (uri, event_key, night, cid, blob_cid, posted_at, reply_to, deleted)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (uri) DO UPDATE SET event_key = excluded.event_key, night = excluded.night, cid = excluded.cid,
+			blob_cid = excluded.blob_cid, posted_at = excluded.posted_at, reply_to = excluded.reply_to,
+			deleted = excluded.deleted`,
+		post.URI, post.EventKey, post.Night.Format(time.DateOnly), post.CID, post.BlobCID,
+		post.PostedAt.Format(time.RFC3339Nano), post.ReplyTo, post.Deleted)
+	return err
+}
+
+func (s *SQLiteStore) ListPosts(date time.Time) ([]model.Post, error) {
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return s.queryPosts(`night BETWEEN ? AND ?`, first.Format(time.DateOnly), first.AddDate(0, 1, -1).Format(time.DateOnly))
+}
+
+func (s *SQLiteStore) PostedOn(night time.Time) ([]model.Post, error) {
+	return s.queryPosts(`night = ? AND deleted = 0`, night.Format(time.DateOnly))
+}
+
+func (s *SQLiteStore) queryPosts(where string, args ...any) ([]model.Post, error) {
+	rows, err := s.db.Query(`SELECT uri, event_key, night, cid, blob_cid, posted_at, reply_to, deleted FROM ledger
+		WHERE `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	posts := []model.Post{}
+	for rows.Next() {
+		var post model.Post
+		var night, postedAt string
+		if err = rows.Scan(&post.URI, &post.EventKey, &night, &post.CID, &post.BlobCID, &postedAt, &post.ReplyTo, &post.Deleted); err != nil {
+			return nil, err
+		}
+		if post.Night, err = time.Parse(time.DateOnly, night); err != nil {
+			return nil, err
+		}
+		if post.PostedAt, err = time.Parse(time.RFC3339Nano, postedAt); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+func (s *SQLiteStore) RecordScrapeRun(run model.ScrapeRun) error {
+	_, err := s.db.Exec(`INSERT INTO scrape_runs (fetched_at, url, hash, month, cached, events, rejected)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		run.FetchedAt.UTC().Format(time.RFC3339Nano), run.URL, run.Hash, monthKey(run.Month), run.Cached, run.Events, run.Rejected)
+	return err
+}
+
+// ListScrapeRuns returns the fetches of the page made in the month of date, oldest first.
+func (s *SQLiteStore) ListScrapeRuns(date time.Time) ([]model.ScrapeRun, error) {
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	rows, err := s.db.Query(`SELECT fetched_at, url, hash, month, cached, events, rejected FROM scrape_runs
+		WHERE fetched_at >= ? AND fetched_at < ? ORDER BY id`,
+		first.Format(time.RFC3339), first.AddDate(0, 1, 0).Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	runs := []model.ScrapeRun{}
+	for rows.Next() {
+		var run model.ScrapeRun
+		var fetchedAt, month string
+		if err = rows.Scan(&fetchedAt, &run.URL, &run.Hash, &month, &run.Cached, &run.Events, &run.Rejected); err != nil {
+			return nil, err
+		}
+		if run.FetchedAt, err = time.Parse(time.RFC3339Nano, fetchedAt); err != nil {
+			return nil, err
+		}
+		if run.Month, err = time.Parse(time.DateOnly, month); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestSQLiteStore(t *testing.T, month time.Time) *SQLiteStore {
+	t.Helper()
+	db, err := OpenSQLiteStore(filepath.Join(t.TempDir(), "events.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db.At(month)
+}
+
+func TestOpenSQLiteStore_migrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+	for i := 0; i < 2; i++ {
+		db, err := OpenSQLiteStore(path)
+		require.NoError(t, err)
+		var version int
+		require.NoError(t, db.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version))
+		require.Equal(t, len(sqliteMigrations), version)
+		require.NoError(t, db.Close())
+	}
+}
+
+func TestSQLiteStore_bookkeeping(t *testing.T) {
+	november := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	s := openTestSQLiteStore(t, november)
+
+	exists, err := s.HasMonth()
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.NoError(t, s.CreateMonth(model.Source{}, novemberEvents()))
+	exists, err = s.HasMonth()
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.ErrorIs(t, s.CreateMonth(model.Source{}, novemberEvents()), ErrExists)
+
+	lines := []model.UnparsedLine{{RawEventString: "TBD – teal", Stage: "date", Offending: "TBD"}}
+	require.NoError(t, s.Quarantine(lines))
+	gotLines, err := s.ListQuarantine(november)
+	require.NoError(t, err)
+	require.Equal(t, lines, gotLines)
+	require.NoError(t, s.Quarantine(nil))
+	gotLines, err = s.ListQuarantine(november)
+	require.NoError(t, err)
+	require.Empty(t, gotLines)
+	require.NoError(t, s.Quarantine(lines))
+
+	scraped := novemberEvents()[1:]
+	scraped[0].Color = "purple"
+	changes, err := Reconcile(s, model.Source{}, scraped)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	logged, err := s.ListChanges(november)
+	require.NoError(t, err)
+	require.Len(t, logged, 2)
+	require.Equal(t, model.ChangeRemoved, logged[0].Kind)
+	require.Nil(t, logged[0].After)
+	require.Equal(t, "purple", logged[1].After.Color)
+
+	night := time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC)
+	post := model.Post{EventKey: "2024-11-06", Night: night, URI: "at://did:plc:abc/app.bsky.feed.post/1", CID: "bafy", PostedAt: time.Date(2024, 11, 6, 17, 0, 0, 0, time.UTC)}
+	require.NoError(t, s.RecordPost(post))
+	posted, err := s.PostedOn(night)
+	require.NoError(t, err)
+	require.Equal(t, []model.Post{post}, posted)
+	post.Deleted = true
+	require.NoError(t, s.RecordPost(post))
+	posted, err = s.PostedOn(night)
+	require.NoError(t, err)
+	require.Empty(t, posted)
+	all, err := s.ListPosts(night)
+	require.NoError(t, err)
+	require.Equal(t, []model.Post{post}, all)
+
+	run := model.ScrapeRun{FetchedAt: time.Date(2024, 11, 6, 16, 0, 0, 0, time.UTC), URL: "https://www.sf.gov", Hash: "abc", Month: november, Events: 2}
+	require.NoError(t, s.RecordScrapeRun(run))
+	runs, err := s.ListScrapeRuns(november)
+	require.NoError(t, err)
+	require.Equal(t, []model.ScrapeRun{run}, runs)
+}
+
+func TestImportFiles(t *testing.T) {
+	from := &FileStore{path: "file-test-fixtures/cross-month-cases"}
+	to := openTestSQLiteStore(t, time.Now())
+
+	months, err := ImportFiles(from, to)
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+	}, months)
+
+	event, err := to.Read(time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, "Tuesday, December 31 through Wednesday, January 1", event.DateString)
+
+	december, err := to.List(time.Date(2024, 12, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	want, err := from.List(time.Date(2024, 12, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, eventKeys(want), eventKeys(december))
+	revisions, err := to.ListRevisions(time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.NotEmpty(t, revisions)
+	require.Equal(t, model.RevisionMigration, revisions[0].Reason)
+
+	months, err = ImportFiles(from, to)
+	require.NoError(t, err)
+	require.Empty(t, months)
+}
@@ -1,15 +1,35 @@
 package store
 
 import (
+	"errors"
 	"time"
 
 	"city-hall-lights/internal/model"
 )
 
+var (
+	ErrNotFound = errors.New("event not found")
+	ErrExists   = errors.New("event already exists")
+)
+
+// Store keeps the events of each month's lighting schedule. A store is scoped to the schedule of one month,
+// which Create, Update and Delete operate on; lookups search every month that may list an event. Events are
+// identified by their Key.
 type Store interface {
+	// Create adds an event to the month's schedule, failing with ErrExists if it already lists the event.
 	Create(event model.Event) error
+	// Update replaces the event with the same key, failing with ErrNotFound if the month doesn't list it.
 	Update(event model.Event) error
+	// Read returns the event lit on date.
 	Read(date time.Time) (model.Event, error)
+	// Get returns the event with the given key.
+	Get(key string) (model.Event, error)
+	// List returns the events lit on any night of the month of date.
 	List(date time.Time) ([]model.Event, error)
+	// ListRange returns the events lit on any night from one date through another.
+	ListRange(from, to time.Time) ([]model.Event, error)
+	// Delete removes the event with the same key, failing with ErrNotFound if the month doesn't list it.
 	Delete(event model.Event) error
 }
+
+var _ Store = (*FileStore)(nil)
@@ -0,0 +1,46 @@
+package store_test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/store"
+	"city-hall-lights/internal/store/storetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) func(month time.Time) store.Store {
+		fs := store.NewFileStore().In(t.TempDir())
+		return func(month time.Time) store.Store {
+			s := fs.At(month)
+			return &s
+		}
+	})
+}
+
+func TestSQLiteStore_conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) func(month time.Time) store.Store {
+		db, err := store.OpenSQLiteStore(filepath.Join(t.TempDir(), "events.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return func(month time.Time) store.Store {
+			return db.At(month)
+		}
+	})
+}
+
+func TestGitStore_conformance(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	storetest.Run(t, func(t *testing.T) func(month time.Time) store.Store {
+		g, err := store.OpenGitStore(t.TempDir(), "")
+		require.NoError(t, err)
+		return func(month time.Time) store.Store {
+			return g.ForMonth(month)
+		}
+	})
+}
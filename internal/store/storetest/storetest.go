@@ -0,0 +1,207 @@
+// Package storetest checks that a store.Store backend keeps the contract FileStore implements, so that every
+// backend can run the same suite.
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"city-hall-lights/internal/model"
+	"city-hall-lights/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+// Backend opens an empty backend for one test and returns views of it scoped to the schedule of a month.
+type Backend func(t *testing.T) func(month time.Time) store.Store
+
+var (
+	november = time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	december = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func event(date string, color string, description string, nights ...string) model.Event {
+	start, _ := time.Parse(time.DateOnly, date)
+	e := model.Event{
+		DateString:     date,
+		StartTimeStamp: start,
+		Color:          color,
+		Description:    description,
+		RawEventString: date + " – " + color + " – " + description,
+	}
+	for _, night := range nights {
+		n, _ := time.Parse(time.DateOnly, night)
+		e.Nights = append(e.Nights, n)
+		e.EndTimeStamp = n
+	}
+	return e
+}
+
+func electionDay() model.Event {
+	return event("2024-11-05", "red/white/blue", "in recognition of Election Day 2024")
+}
+
+func lightTheWorldTeal() model.Event {
+	return event("2024-11-06", "teal", "in recognition of Light the World Teal")
+}
+
+func thanksgiving() model.Event {
+	return event("2024-11-30", "orange", "in celebration of Thanksgiving", "2024-11-30", "2024-12-01")
+}
+
+func newYear() model.Event {
+	return event("2024-12-31", "shades of amber", "in celebration of the New Year", "2024-12-31", "2025-01-01")
+}
+
+// requireSameEvent compares the stored fields of two events, ignoring the location their times are in.
+func requireSameEvent(t *testing.T, want, got model.Event) {
+	t.Helper()
+	require.Equal(t, want.Key(), got.Key())
+	require.Equal(t, want.DateString, got.DateString)
+	require.Equal(t, want.Color, got.Color)
+	require.Equal(t, want.Description, got.Description)
+	require.Equal(t, want.RawEventString, got.RawEventString)
+	require.True(t, want.EndTimeStamp.Equal(got.EndTimeStamp), "end %s, got %s", want.EndTimeStamp, got.EndTimeStamp)
+	require.Len(t, got.Nights, len(want.Nights))
+	for i := range want.Nights {
+		require.True(t, want.Nights[i].Equal(got.Nights[i]), "night %s, got %s", want.Nights[i], got.Nights[i])
+	}
+}
+
+func keys(events []model.Event) []string {
+	keys := []string{}
+	for _, e := range events {
+		keys = append(keys, e.Key())
+	}
+	return keys
+}
+
+func seed(t *testing.T, s store.Store, events ...model.Event) {
+	t.Helper()
+	for _, e := range events {
+		require.NoError(t, s.Create(e))
+	}
+}
+
+// Run runs the conformance suite against a backend.
+func Run(t *testing.T, backend Backend) {
+	t.Run("create and get", func(t *testing.T) {
+		s := backend(t)(november)
+		seed(t, s, lightTheWorldTeal(), electionDay())
+
+		got, err := s.Get("2024-11-05")
+		require.NoError(t, err)
+		requireSameEvent(t, electionDay(), got)
+
+		require.ErrorIs(t, s.Create(electionDay()), store.ErrExists)
+		_, err = s.Get("2024-11-07")
+		require.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("read the event lit on a night", func(t *testing.T) {
+		s := backend(t)(november)
+		seed(t, s, electionDay(), thanksgiving())
+
+		got, err := s.Read(time.Date(2024, 11, 5, 20, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		requireSameEvent(t, electionDay(), got)
+
+		got, err = s.Read(time.Date(2024, 12, 1, 20, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		requireSameEvent(t, thanksgiving(), got)
+
+		_, err = s.Read(time.Date(2024, 11, 6, 20, 0, 0, 0, time.UTC))
+		require.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		s := backend(t)(november)
+		seed(t, s, electionDay(), lightTheWorldTeal())
+
+		recolored := lightTheWorldTeal()
+		recolored.Color = "purple"
+		require.NoError(t, s.Update(recolored))
+		got, err := s.Get(recolored.Key())
+		require.NoError(t, err)
+		requireSameEvent(t, recolored, got)
+
+		require.ErrorIs(t, s.Update(thanksgiving()), store.ErrNotFound)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := backend(t)(november)
+		seed(t, s, electionDay(), lightTheWorldTeal())
+
+		require.NoError(t, s.Delete(electionDay()))
+		_, err := s.Get(electionDay().Key())
+		require.ErrorIs(t, err, store.ErrNotFound)
+		require.ErrorIs(t, s.Delete(electionDay()), store.ErrNotFound)
+
+		got, err := s.List(november)
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024-11-06"}, keys(got))
+	})
+
+	t.Run("list a month including ranges from the months either side", func(t *testing.T) {
+		open := backend(t)
+		seed(t, open(november), electionDay(), lightTheWorldTeal(), thanksgiving())
+		seed(t, open(december), newYear())
+
+		got, err := open(november).List(november)
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024-11-05", "2024-11-06", "2024-11-30"}, keys(got))
+
+		got, err = open(december).List(december)
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024-11-30", "2024-12-31"}, keys(got))
+
+		january := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		got, err = open(december).List(january)
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024-12-31"}, keys(got))
+
+		got, err = open(january).List(january)
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024-12-31"}, keys(got))
+	})
+
+	t.Run("list a range", func(t *testing.T) {
+		open := backend(t)
+		seed(t, open(november), electionDay(), lightTheWorldTeal(), thanksgiving())
+		seed(t, open(december), newYear())
+
+		got, err := open(november).ListRange(time.Date(2024, 11, 6, 0, 0, 0, 0, time.UTC), time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024-11-06", "2024-11-30"}, keys(got))
+
+		got, err = open(november).ListRange(time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024-11-30", "2024-12-31"}, keys(got))
+
+		got, err = open(november).ListRange(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Empty(t, got)
+	})
+
+	t.Run("list a range both months' schedules list once", func(t *testing.T) {
+		open := backend(t)
+		seed(t, open(november), thanksgiving())
+		seed(t, open(december), thanksgiving(), newYear())
+
+		got, err := open(december).ListRange(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Equal(t, []string{"2024-11-30", "2024-12-31"}, keys(got))
+	})
+
+	t.Run("get an event listed on another month's schedule", func(t *testing.T) {
+		open := backend(t)
+		seed(t, open(december), newYear())
+
+		got, err := open(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)).Get("2024-12-31")
+		require.NoError(t, err)
+		requireSameEvent(t, newYear(), got)
+
+		got, err = open(november).Read(time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		requireSameEvent(t, newYear(), got)
+	})
+}